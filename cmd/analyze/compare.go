@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"agent-reliability-tests/pkg/analysis"
+
+	"github.com/spf13/cobra"
+)
+
+// Exit code used when the candidate run regresses
+const exitRegression = 2
+
+var compareOpts = analysis.DefaultCompareOptions()
+
+func newCompareCommand() *cobra.Command {
+	compareCmd := &cobra.Command{
+		Use:   "compare [base_log] [candidate_log]",
+		Short: "Compare two runs and detect reliability regressions",
+		Long: `Analyze a base and a candidate log with the same options and report the
+change in similarity, cluster distribution, assertion pass rate, error rate and
+latency, with significance tests and the similarity between the two runs'
+dominant response patterns.
+
+Exits with status 2 when a metric regresses beyond its threshold.`,
+		Args: cobra.ExactArgs(2),
+		Run:  runCompare,
+	}
+
+	defaults := analysis.DefaultCompareOptions()
+	compareCmd.Flags().Float64Var(&compareOpts.Alpha, "alpha", defaults.Alpha, "Significance level for the statistical tests")
+	compareCmd.Flags().BoolVar(&compareOpts.RequireSignificance, "require-significance", defaults.RequireSignificance, "Only count statistically significant changes as regressions")
+	compareCmd.Flags().Float64Var(&compareOpts.MaxSimilarityDrop, "max-similarity-drop", defaults.MaxSimilarityDrop, "Largest allowed drop in average similarity")
+	compareCmd.Flags().Float64Var(&compareOpts.MaxMajorityShareDrop, "max-majority-drop", defaults.MaxMajorityShareDrop, "Largest allowed drop in the dominant cluster's share")
+	compareCmd.Flags().Float64Var(&compareOpts.MaxPassRateDrop, "max-pass-rate-drop", defaults.MaxPassRateDrop, "Largest allowed drop in assertion pass rate")
+	compareCmd.Flags().Float64Var(&compareOpts.MaxErrorRateIncrease, "max-error-rate-increase", defaults.MaxErrorRateIncrease, "Largest allowed increase in the share of loops with errors")
+	compareCmd.Flags().Float64Var(&compareOpts.MaxLatencyIncrease, "max-latency-increase", defaults.MaxLatencyIncrease, "Largest allowed relative increase in median latency (0.25 = +25%)")
+
+	return compareCmd
+}
+
+func runCompare(cmd *cobra.Command, args []string) {
+	baseLog, candidateLog := args[0], args[1]
+
+	for _, logFile := range args {
+		if _, err := os.Stat(logFile); os.IsNotExist(err) {
+			fmt.Printf("Error: Log file '%s' does not exist\n", logFile)
+			os.Exit(1)
+		}
+	}
+
+	opts, err := buildAnalysisOptions()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	base, err := analysis.AnalyzeLogFileWithOptions(baseLog, opts)
+	if err != nil {
+		fmt.Printf("Error analyzing base log: %v\n", err)
+		os.Exit(1)
+	}
+	candidate, err := analysis.AnalyzeLogFileWithOptions(candidateLog, opts)
+	if err != nil {
+		fmt.Printf("Error analyzing candidate log: %v\n", err)
+		os.Exit(1)
+	}
+
+	if base.TotalEntries == 0 || candidate.TotalEntries == 0 {
+		fmt.Println("Both log files need at least one entry to compare")
+		os.Exit(1)
+	}
+
+	comparison := analysis.CompareRuns(base, candidate, compareOpts)
+	analysis.PrintRunComparison(comparison, baseLog, candidateLog)
+
+	if comparison.Regressed() {
+		os.Exit(exitRegression)
+	}
+}
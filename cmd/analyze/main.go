@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -12,14 +13,47 @@ import (
 )
 
 var (
-	verbose    bool
-	outputFile string
-	debug      bool
+	verbose          bool
+	outputFile       string
+	debug            bool
+	clusterMethod    string
+	clusterThreshold float64
+	clusterEps       float64
+	clusterMinPoints int
+	clusterK         int
+	outlierMethod    string
+	outlierThreshold float64
+	lofNeighbours    int
+	assertionsFile   string
+	scoringFile      string
+	outputFormat     string
+	bootstrapIters   int
+	confidence       float64
+	targetMargin     float64
+	bootstrapSeed    int64
+	metricName       string
+	embedURL         string
+	embedModel       string
+	embedCache       string
+	judgeCommand     string
+	judgeRubric      string
+	judgeReference   string
+	judgeCache       string
+	judgeWorkers     int
+	vetCode          bool
+	expectAgent      string
+	relayInstruction string
+	planAnalysis     bool
+	planThreshold    float64
+	behaviourFile    string
+	constraintsFile  string
+	answerSpecs      []string
+	answerCase       bool
 )
 
 func main() {
 	var rootCmd = &cobra.Command{
-		Use:   "analyze [log_file]",
+		Use:   "analyze [log_file | directory | glob]...",
 		Short: "Analyze Claude agent reliability test logs",
 		Long: `Analyze Claude agent reliability test logs to quantify response similarity,
 identify common patterns, and detect abnormal responses.
@@ -29,15 +63,59 @@ The analysis provides:
 - Clustering of similar responses  
 - Most common response pattern
 - Most abnormal/outlier response
-- Reliability assessment`,
-		Args: cobra.ExactArgs(1),
+- Reliability assessment
+
+Given several files, a directory or a glob, the logs are grouped by agent and
+template and reported as a time series of reliability metrics.`,
+		Args: cobra.MinimumNArgs(1),
 		Run:  runAnalysis,
 	}
 
 	rootCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose output including similarity matrix")
 	rootCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Save detailed results to file")
+	rootCmd.Flags().StringVar(&outputFormat, "format", "text", "Output format: text, json (written to --output or stdout) or html (written to --output or <log>.html); trends support text, csv and json")
 	rootCmd.Flags().BoolVarP(&debug, "debug", "d", false, "Show extracted responses for debugging")
 
+	// Analysis options are shared with subcommands
+	defaults := analysis.DefaultClusterOptions()
+	rootCmd.PersistentFlags().StringVar(&clusterMethod, "cluster-method", string(defaults.Method), "Clustering method: greedy, single, average, complete, dbscan, kmedoids")
+	rootCmd.PersistentFlags().Float64Var(&clusterThreshold, "cluster-threshold", defaults.Threshold, "Minimum similarity for greedy and hierarchical merges")
+	rootCmd.PersistentFlags().Float64Var(&clusterEps, "eps", defaults.Eps, "DBSCAN: minimum similarity for two responses to be neighbours")
+	rootCmd.PersistentFlags().IntVar(&clusterMinPoints, "min-points", defaults.MinPoints, "DBSCAN: neighbours needed for a core response")
+	rootCmd.PersistentFlags().IntVar(&clusterK, "k", 0, "k-medoids: number of clusters (0 selects k by silhouette)")
+	rootCmd.PersistentFlags().StringVar(&outlierMethod, "outlier-method", string(analysis.DefaultOutlierOptions().Method), "Outlier detection method: mad (median/MAD z-score) or lof (local outlier factor)")
+	rootCmd.PersistentFlags().Float64Var(&outlierThreshold, "outlier-threshold", 0, "Score above which a response is flagged (0 uses the method default: 3.5 for mad, 1.5 for lof)")
+	rootCmd.PersistentFlags().IntVar(&lofNeighbours, "lof-neighbours", 0, "LOF: neighbourhood size (0 uses min(5, n-1))")
+	rootCmd.PersistentFlags().StringVarP(&assertionsFile, "assertions", "a", "", "Path to a JSON assertions file checking responses against expected outputs")
+	rootCmd.PersistentFlags().StringVar(&constraintsFile, "constraints", "", "Path to a JSON file of format constraints every response must follow (default: <template>.constraints.json next to the log's template)")
+	rootCmd.PersistentFlags().StringArrayVar(&answerSpecs, "answer", nil, "Extract the final answer for majority voting: regex:<pattern>, last-line, last-number or json:<path> (repeat to try several in order)")
+	rootCmd.PersistentFlags().BoolVar(&answerCase, "answer-case-sensitive", false, "Compare extracted answers case-sensitively")
+	rootCmd.PersistentFlags().StringVar(&behaviourFile, "behaviour-rules", "", "Path to a JSON file of rules labelling responses (answered, refused, clarification, ...), checked before the defaults")
+	rootCmd.PersistentFlags().StringVar(&scoringFile, "scoring", "", "Path to a JSON scoring policy with metric weights and grade bands")
+
+	bootstrap := analysis.DefaultBootstrapOptions()
+	rootCmd.PersistentFlags().IntVar(&bootstrapIters, "bootstrap", bootstrap.Iterations, "Number of bootstrap resamples for confidence intervals (0 disables)")
+	rootCmd.PersistentFlags().Float64Var(&confidence, "confidence", bootstrap.Confidence, "Confidence level for bootstrap intervals")
+	rootCmd.PersistentFlags().Float64Var(&targetMargin, "margin", bootstrap.TargetMargin, "Target margin of error for the loops-needed estimate")
+	rootCmd.PersistentFlags().Int64Var(&bootstrapSeed, "seed", bootstrap.Seed, "Random seed for bootstrap resampling")
+
+	rootCmd.PersistentFlags().StringVar(&metricName, "metric", "lexical", "Similarity metric: lexical (edit distance and word overlap), embedding (cosine similarity of embeddings), judge (LLM equivalence scores) or go-ast (structure of Go code blocks)")
+	rootCmd.PersistentFlags().StringVar(&embedURL, "embed-url", analysis.DefaultEmbeddingURL, "OpenAI-compatible embeddings endpoint for --metric embedding")
+	rootCmd.PersistentFlags().StringVar(&embedModel, "embed-model", "nomic-embed-text", "Embedding model for --metric embedding")
+	rootCmd.PersistentFlags().StringVar(&embedCache, "embed-cache", analysis.DefaultEmbeddingCacheDir(), "Directory caching embeddings by content hash (empty disables the cache)")
+	rootCmd.PersistentFlags().StringVar(&judgeCommand, "judge-command", "claude -p", "Command that reads a grading prompt on stdin and prints a JSON verdict")
+	rootCmd.PersistentFlags().StringVar(&judgeRubric, "judge-rubric", "", "Path to a file replacing the default judge rubric")
+	rootCmd.PersistentFlags().StringVar(&judgeReference, "judge-reference", "", "Path to a reference answer every response is judged against")
+	rootCmd.PersistentFlags().StringVar(&judgeCache, "judge-cache", analysis.DefaultJudgeCacheDir(), "Directory caching judge verdicts (empty disables the cache)")
+	rootCmd.PersistentFlags().IntVar(&judgeWorkers, "judge-workers", 4, "Judge commands run concurrently")
+	rootCmd.PersistentFlags().StringVar(&expectAgent, "expect-agent", "", "Subagent every loop should call (default: the agent in the log header)")
+	rootCmd.PersistentFlags().StringVar(&relayInstruction, "relay-instruction", "", "Instruction the subagent should receive (default: extracted from each prompt)")
+	rootCmd.PersistentFlags().BoolVar(&planAnalysis, "plan", false, "Compare the structure of implementation plans: steps, agent assignments and step counts")
+	rootCmd.PersistentFlags().Float64Var(&planThreshold, "plan-threshold", analysis.DefaultPlanOptions().MatchThreshold, "Minimum title similarity for plan steps to align")
+	rootCmd.PersistentFlags().BoolVar(&vetCode, "vet-code", false, "Run go vet on Go code blocks in a temporary module (requires the go toolchain)")
+
+	rootCmd.AddCommand(newCompareCommand())
+
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
@@ -45,6 +123,10 @@ The analysis provides:
 }
 
 func runAnalysis(cmd *cobra.Command, args []string) {
+	if isTrendRequest(args) {
+		runTrendAnalysis(args)
+		return
+	}
 	logFile := args[0]
 
 	// Check if file exists
@@ -53,12 +135,30 @@ func runAnalysis(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	opts, err := buildAnalysisOptions()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch outputFormat {
+	case "text":
+	case "json":
+		runJSONAnalysis(logFile, opts)
+		return
+	case "html":
+		runHTMLAnalysis(logFile, opts)
+		return
+	default:
+		fmt.Printf("Error: unknown format %q (expected text, json or html)\n", outputFormat)
+		os.Exit(1)
+	}
+
 	fmt.Printf("Analyzing log file: %s\n", logFile)
 	fmt.Println("Processing...")
 
 	// Parse entries for debug mode (if needed)
 	var entries []analysis.LogEntry
-	var err error
 	if debug {
 		entries, err = analysis.ParseLogFile(logFile)
 		if err != nil {
@@ -68,7 +168,7 @@ func runAnalysis(cmd *cobra.Command, args []string) {
 	}
 
 	// Perform dual agent analysis
-	result, err := analysis.AnalyzeLogFile(logFile)
+	result, err := analysis.AnalyzeLogFileWithOptions(logFile, opts)
 	if err != nil {
 		fmt.Printf("Error analyzing log file: %v\n", err)
 		os.Exit(1)
@@ -105,6 +205,227 @@ func runAnalysis(cmd *cobra.Command, args []string) {
 	}
 }
 
+// runJSONAnalysis writes the analysis as a JSON report to --output or stdout,
+// keeping progress messages on stderr so stdout stays machine-readable
+func runJSONAnalysis(logFile string, opts analysis.AnalysisOptions) {
+	fmt.Fprintf(os.Stderr, "Analyzing log file: %s\n", logFile)
+
+	result, err := analysis.AnalyzeLogFileWithOptions(logFile, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error analyzing log file: %v\n", err)
+		os.Exit(1)
+	}
+
+	report := analysis.BuildReport(result, logFile)
+	if outputFile == "" {
+		if err := analysis.WriteJSONReport(os.Stdout, report); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := saveJSONReport(report, outputFile); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving report: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "JSON report saved to: %s\n", outputFile)
+}
+
+// runHTMLAnalysis writes the analysis as a self-contained HTML report
+func runHTMLAnalysis(logFile string, opts analysis.AnalysisOptions) {
+	fmt.Printf("Analyzing log file: %s\n", logFile)
+
+	result, err := analysis.AnalyzeLogFileWithOptions(logFile, opts)
+	if err != nil {
+		fmt.Printf("Error analyzing log file: %v\n", err)
+		os.Exit(1)
+	}
+
+	filename := outputFile
+	if filename == "" {
+		filename = strings.TrimSuffix(logFile, filepath.Ext(logFile)) + ".html"
+	}
+	report := analysis.BuildReport(result, logFile)
+	if err := writeOutputFile(filename, func(w io.Writer) error {
+		return analysis.WriteHTMLReport(w, report)
+	}); err != nil {
+		fmt.Printf("Error saving report: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("HTML report saved to: %s\n", filename)
+}
+
+func saveJSONReport(report *analysis.Report, filename string) error {
+	return writeOutputFile(filename, func(w io.Writer) error {
+		return analysis.WriteJSONReport(w, report)
+	})
+}
+
+// writeOutputFile creates a file, and its directory, and writes it. The file
+// is removed if writing or closing it fails, so no partial report is left.
+func writeOutputFile(filename string, write func(io.Writer) error) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	err = write(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(filename)
+		return err
+	}
+	return nil
+}
+
+// buildAnalysisOptions converts command line flags into analysis options
+func buildAnalysisOptions() (analysis.AnalysisOptions, error) {
+	opts := analysis.DefaultAnalysisOptions()
+
+	method, err := analysis.ParseClusterMethod(clusterMethod)
+	if err != nil {
+		return opts, err
+	}
+	opts.Clustering.Method = method
+	opts.Clustering.Threshold = clusterThreshold
+	opts.Clustering.Eps = clusterEps
+	opts.Clustering.MinPoints = clusterMinPoints
+	opts.Clustering.K = clusterK
+
+	outliers, err := analysis.ParseOutlierMethod(outlierMethod)
+	if err != nil {
+		return opts, err
+	}
+	opts.Outliers.Method = outliers
+	opts.Outliers.Threshold = outlierThreshold
+	opts.Outliers.Neighbours = lofNeighbours
+
+	if assertionsFile != "" {
+		assertions, err := analysis.LoadAssertions(assertionsFile)
+		if err != nil {
+			return opts, err
+		}
+		opts.Assertions = assertions
+	}
+
+	if constraintsFile != "" {
+		constraints, err := analysis.LoadConstraints(constraintsFile)
+		if err != nil {
+			return opts, err
+		}
+		opts.Constraints = constraints
+	}
+
+	for _, spec := range answerSpecs {
+		extractor, err := analysis.ParseAnswerExtractor(spec)
+		if err != nil {
+			return opts, err
+		}
+		opts.Answers.Extractors = append(opts.Answers.Extractors, extractor)
+	}
+	opts.Answers.CaseSensitive = answerCase
+
+	if behaviourFile != "" {
+		rules, err := analysis.LoadBehaviourRules(behaviourFile)
+		if err != nil {
+			return opts, err
+		}
+		opts.Behaviour = rules
+	}
+
+	if scoringFile != "" {
+		policy, err := analysis.LoadScoringPolicy(scoringFile)
+		if err != nil {
+			return opts, err
+		}
+		opts.Scoring = policy
+	}
+
+	if confidence <= 0 || confidence >= 1 {
+		return opts, fmt.Errorf("--confidence must be between 0 and 1, got %g", confidence)
+	}
+	opts.Bootstrap.Iterations = bootstrapIters
+	opts.Bootstrap.Confidence = confidence
+	opts.Bootstrap.TargetMargin = targetMargin
+	opts.Bootstrap.Seed = bootstrapSeed
+	opts.Code.Vet = vetCode
+	opts.ExpectedAgent = expectAgent
+	opts.Relay.Instruction = relayInstruction
+	opts.Plan.Enabled = planAnalysis
+	opts.Plan.MatchThreshold = planThreshold
+
+	var judge *analysis.Judge
+	if metricName == "judge" || judgeReference != "" {
+		judge, err = buildJudge()
+		if err != nil {
+			return opts, err
+		}
+	}
+	if judgeReference != "" {
+		reference, err := os.ReadFile(judgeReference)
+		if err != nil {
+			return opts, fmt.Errorf("failed to read judge reference: %w", err)
+		}
+		opts.Judge = judge
+		opts.JudgeReference = strings.TrimSpace(string(reference))
+	}
+
+	metric, err := buildMetric(judge)
+	if err != nil {
+		return opts, err
+	}
+	opts.Metric = metric
+
+	return opts, nil
+}
+
+// buildMetric creates the similarity metric selected by --metric
+func buildMetric(judge *analysis.Judge) (analysis.SimilarityMetric, error) {
+	switch metricName {
+	case "lexical":
+		return analysis.LexicalMetric{}, nil
+	case "embedding":
+		embedder := analysis.NewHTTPEmbedder(embedURL, embedModel)
+		embedder.APIKey = os.Getenv("EMBEDDINGS_API_KEY")
+		if embedCache == "" {
+			return analysis.EmbeddingMetric{Embedder: embedder}, nil
+		}
+		return analysis.EmbeddingMetric{Embedder: analysis.NewCachedEmbedder(embedder, embedCache, embedModel)}, nil
+	case "judge":
+		return analysis.JudgeMetric{Judge: judge}, nil
+	case "go-ast":
+		return analysis.GoASTMetric{}, nil
+	default:
+		return nil, fmt.Errorf("unknown metric %q (expected lexical, embedding, judge or go-ast)", metricName)
+	}
+}
+
+// buildJudge creates the judge configured by the --judge-* flags
+func buildJudge() (*analysis.Judge, error) {
+	command := strings.Fields(judgeCommand)
+	if len(command) == 0 {
+		return nil, fmt.Errorf("--judge-command is empty")
+	}
+	judge := analysis.NewJudge(command)
+	judge.CacheDir = judgeCache
+	judge.Workers = judgeWorkers
+	if judgeRubric != "" {
+		rubric, err := os.ReadFile(judgeRubric)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read judge rubric: %w", err)
+		}
+		judge.Rubric = strings.TrimSpace(string(rubric))
+	}
+	return judge, nil
+}
+
 func printVerboseOutput(result *analysis.DualAgentAnalysisResult) {
 	// Print verbose output for Main Agent
 	if result.MainAgentAnalysis != nil {
@@ -151,8 +472,8 @@ func printVerboseAnalysis(result *analysis.AnalysisResult, agentName string) {
 
 	fmt.Printf("\n--- %s DETAILED CLUSTERS ---\n", strings.ToUpper(agentName))
 	for i, cluster := range result.Clusters {
-		fmt.Printf("Cluster %d (%d responses):\n", i+1, cluster.Size)
-		fmt.Printf("  Representative: \"%s\"\n", truncateString(cluster.Centroid, 100))
+		fmt.Printf("Cluster %d (%d responses, silhouette %.3f):\n", i+1, cluster.Size, cluster.Silhouette)
+		fmt.Printf("  Medoid (response %d): \"%s\"\n", cluster.Medoid, truncateString(cluster.Centroid, 100))
 		fmt.Printf("  Response indices: %v\n", cluster.Responses)
 		if i >= 4 { // Limit to first 5 clusters
 			remaining := len(result.Clusters) - 5
@@ -162,6 +483,31 @@ func printVerboseAnalysis(result *analysis.AnalysisResult, agentName string) {
 			break
 		}
 	}
+
+	fmt.Printf("\n--- %s DIFFS AGAINST MEDOID ---\n", strings.ToUpper(agentName))
+	if result.AbnormalDiff != nil {
+		printDiff(fmt.Sprintf("Most abnormal (loop %d) vs medoid (loop %d)", result.MostAbnormal.Loop, result.AbnormalReference), *result.AbnormalDiff)
+	}
+	for _, outlier := range result.Outliers {
+		if outlier.Diff == nil || outlier.Entry.Loop == result.MostAbnormal.Loop {
+			continue
+		}
+		printDiff(fmt.Sprintf("Outlier loop %d vs medoid (loop %d)", outlier.Entry.Loop, outlier.ReferenceLoop), *outlier.Diff)
+	}
+	if result.AbnormalDiff == nil && len(result.Outliers) == 0 {
+		fmt.Println("No responses to diff")
+	}
+}
+
+// printDiff prints a colored diff with deletions in red and insertions in green
+func printDiff(title string, diff analysis.ResponseDiff) {
+	fmt.Printf("%s (%s level, %d removed, %d added):\n", title, diff.Mode, diff.Deleted, diff.Inserted)
+	if !diff.Changed() {
+		fmt.Println("  (identical)")
+		return
+	}
+	fmt.Println(diff.ANSI())
+	fmt.Println()
 }
 
 func saveDualAgentResults(result *analysis.DualAgentAnalysisResult, filename string) error {
@@ -185,6 +531,71 @@ func saveDualAgentResults(result *analysis.DualAgentAnalysisResult, filename str
 	fmt.Fprintf(file, "Main Agent Responses: %d\n", len(result.MainAgentResponses))
 	fmt.Fprintf(file, "Sub Agent Responses: %d\n\n", len(result.SubAgentResponses))
 
+	if len(result.Warnings) > 0 {
+		fmt.Fprintf(file, "Parse Warnings: %d\n", len(result.Warnings))
+		for _, warning := range result.Warnings {
+			fmt.Fprintf(file, "  %s\n", warning)
+		}
+		fmt.Fprintf(file, "\n")
+	}
+
+	if stats := result.Latency; stats != nil {
+		fmt.Fprintf(file, "Latency (%d timed loops): min %.3fs, median %.3fs, p90 %.3fs, p99 %.3fs, max %.3fs (loop %d)\n",
+			stats.Samples, stats.Min, stats.Median, stats.P90, stats.P99, stats.Max, stats.SlowestLoop)
+		fmt.Fprintf(file, "Latency mean %.3fs, std dev %.3fs, coefficient of variation %.4f\n\n", stats.Mean, stats.StdDev, stats.CV)
+	}
+
+	if summary := result.Errors; summary != nil {
+		fmt.Fprintf(file, "Loops With Errors: %d/%d (%.4f)\n", summary.LoopsWithErrors, summary.Entries, summary.ErrorRate)
+		for _, class := range summary.Classes {
+			fmt.Fprintf(file, "  %s: %d loops, %d messages %v - %s\n",
+				class.Class, len(class.Loops), class.Messages, class.Loops, class.Example)
+		}
+		fmt.Fprintf(file, "Empty Responses: %v\n\n", summary.EmptyResponseLoops)
+	}
+
+	if summary := result.Delegation; summary != nil {
+		fmt.Fprintf(file, "Delegation to %q: %d/%d traced loops (%.4f), %d untraced\n",
+			summary.Expected, summary.LoopsInvoked, summary.TracedLoops, summary.InvocationRate, summary.UntracedLoops)
+		for _, agent := range summary.Agents {
+			fmt.Fprintf(file, "  %s: %d calls in %d loops\n", agent.Agent, agent.Calls, agent.Loops)
+		}
+		fmt.Fprintf(file, "No Subagent Call: %v\n", summary.NoCallLoops)
+		fmt.Fprintf(file, "Different Agent Called: %v\n", summary.MismatchLoops)
+		fmt.Fprintf(file, "Repeated Calls: %v\n", summary.RepeatedCallLoops)
+		for _, loop := range summary.Loops {
+			fmt.Fprintf(file, "  Loop %d: %d subagent calls %v, expected agent called %t\n",
+				loop.Loop, loop.SubagentCalls, loop.Agents, loop.Invoked)
+		}
+		fmt.Fprintf(file, "\n")
+	}
+
+	if summary := result.Relay; summary != nil {
+		fmt.Fprintf(file, "Relay Fidelity: %d/%d faithful (%.4f)\n", summary.Faithful, summary.Checked, summary.FaithfulRate)
+		fmt.Fprintf(file, "Mean Coverage: %.4f, Mean Drift: %.4f, Mean Reply Coverage: %.4f\n",
+			summary.MeanCoverage, summary.MeanDrift, summary.MeanReplyCoverage)
+		for _, check := range summary.Loops {
+			fmt.Fprintf(file, "  Loop %d: faithful %t, coverage %.4f, drift %.4f, reply coverage %.4f\n",
+				check.Loop, check.Faithful, check.Coverage, check.Drift, check.ReplyCoverage)
+			fmt.Fprintf(file, "    Instruction: %s\n", check.Instruction)
+			fmt.Fprintf(file, "    Relayed: %s\n", check.Relayed)
+			fmt.Fprintf(file, "    Dropped terms %v, added terms %v\n", check.DroppedTerms, check.AddedTerms)
+			for _, clause := range check.DroppedInstructions {
+				fmt.Fprintf(file, "    Dropped: %s\n", clause)
+			}
+			for _, literal := range check.DroppedLiterals {
+				fmt.Fprintf(file, "    Dropped literal: %s\n", literal)
+			}
+			for _, clause := range check.AddedInstructions {
+				fmt.Fprintf(file, "    Added: %s\n", clause)
+			}
+			for _, literal := range check.ReplyMissing {
+				fmt.Fprintf(file, "    Missing from reply: %s\n", literal)
+			}
+		}
+		fmt.Fprintf(file, "Skipped Loops: %v\n\n", summary.SkippedLoops)
+	}
+
 	// Save Main Agent Analysis
 	if result.MainAgentAnalysis != nil {
 		fmt.Fprintf(file, "=== MAIN AGENT ANALYSIS ===\n")
@@ -203,14 +614,198 @@ func saveDualAgentResults(result *analysis.DualAgentAnalysisResult, filename str
 
 func saveAnalysisToFile(file *os.File, result *analysis.AnalysisResult, agentName string) {
 	fmt.Fprintf(file, "Total Responses: %d\n", result.TotalResponses)
+	fmt.Fprintf(file, "Similarity Metric: %s\n", result.Metric)
 	fmt.Fprintf(file, "Average Similarity: %.4f\n", result.AverageSimilarity)
+	if result.Answers != nil && result.Answers.Extracted > 0 {
+		fmt.Fprintf(file, "Answer Agreement: %.4f (%d/%d answers, entropy %.4f bits)\n",
+			result.Answers.Agreement, result.Answers.MajorityCount, result.Answers.Extracted, result.Answers.Entropy)
+	}
+	if result.Constraints != nil {
+		fmt.Fprintf(file, "Format Compliance: %.4f (%d/%d loops)\n",
+			result.Constraints.ComplianceRate, result.Constraints.LoopsCompliant, result.Constraints.LoopsEvaluated)
+	}
 	fmt.Fprintf(file, "Most Common Pattern Count: %d\n", result.MostCommonCount)
-	fmt.Fprintf(file, "Abnormality Score: %.4f\n\n", result.AbnormalityScore)
+	fmt.Fprintf(file, "Abnormality Score: %.4f\n", result.AbnormalityScore)
+	fmt.Fprintf(file, "Cluster Method: %s\n", result.ClusterMethod)
+	fmt.Fprintf(file, "Silhouette Score: %.4f\n\n", result.Silhouette)
 
 	fmt.Fprintf(file, "Most Common Pattern:\n%s\n\n", result.MostCommonPattern)
 
 	fmt.Fprintf(file, "Most Abnormal Response (Loop %d):\n%s\n\n",
 		result.MostAbnormal.Loop, result.MostAbnormal.MainAgentResponse+" "+result.MostAbnormal.SubAgentResponse)
+	if result.AbnormalDiff != nil {
+		fmt.Fprintf(file, "Diff against medoid (loop %d), [-removed-] {+added+}:\n%s\n\n",
+			result.AbnormalReference, result.AbnormalDiff.Markers())
+	}
+
+	fmt.Fprintf(file, "Outliers (%s, threshold %.2f):\n", result.OutlierMethod, result.OutlierThreshold)
+	if len(result.Outliers) == 0 {
+		fmt.Fprintf(file, "None\n")
+	}
+	for _, outlier := range result.Outliers {
+		fmt.Fprintf(file, "Loop %d: score %.4f, mean similarity %.4f, nearest normal loop %d (%.4f)\n",
+			outlier.Entry.Loop, outlier.Score, outlier.MeanSimilarity,
+			outlier.NearestNormalLoop, outlier.NearestNormalSimilarity)
+		fmt.Fprintf(file, "  %s\n", outlier.Entry.MainAgentResponse)
+		if outlier.Diff != nil {
+			fmt.Fprintf(file, "  Diff against medoid (loop %d), [-removed-] {+added+}:\n  %s\n",
+				outlier.ReferenceLoop, strings.ReplaceAll(outlier.Diff.Markers(), "\n", "\n  "))
+		}
+	}
+	fmt.Fprintf(file, "\n")
+
+	if result.Assertions != nil {
+		saveAssertionsToFile(file, result.Assertions)
+	}
+
+	if summary := result.Answers; summary != nil {
+		fmt.Fprintf(file, "Answer Extractors: %s\n", strings.Join(summary.Extractors, ", "))
+		fmt.Fprintf(file, "Answers Extracted: %d/%d\n", summary.Extracted, summary.Responses)
+		fmt.Fprintf(file, "Majority Answer: %s\n", summary.Majority)
+		for _, count := range summary.Distribution {
+			fmt.Fprintf(file, "  %q: %d loops (%.4f) %v\n", count.Answer, len(count.Loops), count.Share, count.Loops)
+		}
+		for _, loop := range summary.Loops {
+			fmt.Fprintf(file, "  Loop %d: %q via %s\n", loop.Loop, loop.Raw, loop.Extractor)
+		}
+		fmt.Fprintf(file, "Deviating Loops: %v\n", summary.DeviantLoops)
+		fmt.Fprintf(file, "No Answer: %v\n\n", summary.NoAnswerLoops)
+	}
+
+	if summary := result.Constraints; summary != nil {
+		fmt.Fprintf(file, "Format Constraints:\n")
+		for _, violation := range summary.Violations {
+			fmt.Fprintf(file, "  %s: %d/%d violated %v\n",
+				violation.Constraint, violation.Violated, violation.Evaluated, violation.Loops)
+		}
+		for _, loop := range summary.Loops {
+			for _, message := range loop.Violations {
+				fmt.Fprintf(file, "  Loop %d %s\n", loop.Loop, message)
+			}
+		}
+		fmt.Fprintf(file, "\n")
+	}
+
+	if summary := result.Behaviour; summary != nil {
+		fmt.Fprintf(file, "Behaviour: answered %.4f of %d loops\n", summary.AnsweredRate, summary.Classified)
+		for _, count := range summary.Labels {
+			fmt.Fprintf(file, "  %s: %d loops (%.4f) %v\n", count.Label, len(count.Loops), count.Rate, count.Loops)
+		}
+		for _, loop := range summary.Loops {
+			if loop.Label != analysis.BehaviourAnswered {
+				fmt.Fprintf(file, "  Loop %d: %s (%s)\n", loop.Loop, loop.Label, loop.Rule)
+			}
+		}
+		fmt.Fprintf(file, "\n")
+	}
+
+	if summary := result.Code; summary != nil {
+		fmt.Fprintf(file, "Loops With Code: %d/%d\n", summary.LoopsWithCode, summary.Responses)
+		for _, language := range summary.Languages {
+			fmt.Fprintf(file, "  %s: %d blocks in %d loops\n", language.Language, language.Blocks, language.Loops)
+		}
+		fmt.Fprintf(file, "Code Similarity: %.4f\n", summary.CodeSimilarity)
+		fmt.Fprintf(file, "Prose Similarity: %.4f\n", summary.ProseSimilarity)
+		if summary.GoBlocks > 0 {
+			fmt.Fprintf(file, "Go Blocks: %d, parsed %d, gofmt clean %d, vetted %d, vet clean %d\n",
+				summary.GoBlocks, summary.GoParsed, summary.GoFormatted, summary.GoVetted, summary.GoVetClean)
+		}
+		for _, loop := range summary.Loops {
+			for _, check := range loop.Go {
+				fmt.Fprintf(file, "  Loop %d block %d: %s\n", loop.Loop, check.Block+1, check.Status())
+				if check.Vetted && !check.VetClean {
+					fmt.Fprintf(file, "    %s\n", strings.ReplaceAll(check.VetOutput, "\n", "\n    "))
+				}
+			}
+		}
+		fmt.Fprintf(file, "\n")
+	}
+
+	if summary := result.Fields; summary != nil {
+		fmt.Fprintf(file, "JSON Fields: %d/%d JSON responses, structure agreement %.4f, %d flaky of %d fields, mean consistency %.4f\n",
+			summary.JSONLoops, summary.Responses, summary.StructureAgreement, summary.FlakyFields, len(summary.Fields), summary.MeanConsistency)
+		for _, field := range summary.Fields {
+			fmt.Fprintf(file, "  %s: consistency %.4f, presence %.4f, %s %.4f",
+				field.Path, field.Consistency, field.PresenceRate, field.Kind, field.TypeStability)
+			switch field.Kind {
+			case "object":
+			case "array":
+				fmt.Fprintf(file, ", set overlap %.4f", field.SetOverlap)
+			default:
+				fmt.Fprintf(file, ", agreement %.4f, majority %s, deviant loops %v",
+					field.ValueAgreement, field.MajorityValue, field.DeviantLoops)
+			}
+			if len(field.MissingLoops) > 0 {
+				fmt.Fprintf(file, ", missing in loops %v", field.MissingLoops)
+			}
+			fmt.Fprintf(file, "\n")
+		}
+		fmt.Fprintf(file, "No JSON: %v\n\n", summary.NonJSONLoops)
+	}
+
+	if summary := result.Plan; summary != nil {
+		fmt.Fprintf(file, "Plan Structure: %d loops with a plan, steps mean %.4f, std dev %.4f, CV %.4f, range %d-%d\n",
+			summary.LoopsWithPlan, summary.MeanSteps, summary.StepStdDev, summary.StepCV, summary.MinSteps, summary.MaxSteps)
+		fmt.Fprintf(file, "Step Alignment: %.4f\n", summary.MeanAlignment)
+		fmt.Fprintf(file, "Agent Agreement: %.4f\n", summary.AgentAgreement)
+		fmt.Fprintf(file, "Reference Plan (loop %d):\n", summary.ReferenceLoop)
+		for i, step := range summary.ReferenceSteps {
+			fmt.Fprintf(file, "  %d. %s [%s]\n", i+1, step.Title, step.Agent)
+		}
+		for _, loop := range summary.Loops {
+			fmt.Fprintf(file, "  Loop %d: %d steps, alignment %.4f, agent agreement %.4f\n",
+				loop.Loop, len(loop.Steps), loop.Alignment, loop.AgentAgreement)
+			for _, title := range loop.Missing {
+				fmt.Fprintf(file, "    Missing: %s\n", title)
+			}
+			for _, title := range loop.Extra {
+				fmt.Fprintf(file, "    Extra: %s\n", title)
+			}
+			for _, title := range loop.Reassigned {
+				fmt.Fprintf(file, "    Reassigned: %s\n", title)
+			}
+		}
+		fmt.Fprintf(file, "No Plan: %v\n\n", summary.LoopsWithoutPlan)
+	}
+
+	if summary := result.Judgments; summary != nil {
+		fmt.Fprintf(file, "Judge Equivalence: %.4f (%d/%d), mean score %.4f\n",
+			summary.Rate, summary.Equivalent, summary.Judged, summary.MeanScore)
+		for _, judged := range summary.Results {
+			fmt.Fprintf(file, "  Loop %d: equivalent %t, score %.4f - %s\n",
+				judged.Loop, judged.Verdict.Equivalent, judged.Verdict.Score, judged.Verdict.Reason)
+		}
+		fmt.Fprintf(file, "\n")
+	}
+
+	if correlation := result.Latency; correlation != nil {
+		fmt.Fprintf(file, "Latency Correlation: length Pearson %.4f, Spearman %.4f, cluster eta squared %.4f\n",
+			correlation.LengthPearson, correlation.LengthSpearman, correlation.ClusterEffect)
+		for _, cluster := range correlation.Clusters {
+			fmt.Fprintf(file, "  Cluster %d: median %.3fs, mean %.3fs (%d timed)\n",
+				cluster.Cluster, cluster.Median, cluster.Mean, cluster.Samples)
+		}
+		fmt.Fprintf(file, "\n")
+	}
+
+	if result.Intervals != nil {
+		fmt.Fprintf(file, "Confidence Intervals (%d resamples, target margin %.4f):\n",
+			result.Intervals.Iterations, result.Intervals.TargetMargin)
+		saveIntervalToFile(file, "Average Similarity", result.Intervals.AverageSimilarity)
+		saveIntervalToFile(file, "Majority Share", result.Intervals.MajorityShare)
+		saveIntervalToFile(file, "Pass Rate", result.Intervals.PassRate)
+		fmt.Fprintf(file, "\n")
+	}
+
+	if result.Score != nil {
+		fmt.Fprintf(file, "Reliability Grade: %s\n", result.Score.Grade)
+		fmt.Fprintf(file, "Composite Score: %.4f\n", result.Score.Composite)
+		for _, c := range result.Score.Components {
+			fmt.Fprintf(file, "  %s: value %.4f, score %.4f, weight %.4f, contribution %.4f\n",
+				c.Metric, c.Value, c.Score, c.Weight, c.Contribution)
+		}
+		fmt.Fprintf(file, "Limiting Metric: %s\n\n", result.Score.Explanation)
+	}
 
 	fmt.Fprintf(file, "Similarity Matrix:\n")
 	for i, row := range result.SimilarityMatrix {
@@ -223,10 +818,38 @@ func saveAnalysisToFile(file *os.File, result *analysis.AnalysisResult, agentNam
 
 	fmt.Fprintf(file, "\nClusters:\n")
 	for i, cluster := range result.Clusters {
-		fmt.Fprintf(file, "Cluster %d: %d responses - %v\n",
-			i+1, cluster.Size, cluster.Responses)
-		fmt.Fprintf(file, "  Centroid: %s\n", cluster.Centroid)
+		fmt.Fprintf(file, "Cluster %d: %d responses - %v (silhouette %.4f)\n",
+			i+1, cluster.Size, cluster.Responses, cluster.Silhouette)
+		fmt.Fprintf(file, "  Medoid (response %d): %s\n", cluster.Medoid, cluster.Centroid)
+	}
+}
+
+func saveAssertionsToFile(file *os.File, summary *analysis.AssertionSummary) {
+	fmt.Fprintf(file, "Assertion Pass Rate: %.4f (%d/%d loops)\n",
+		summary.PassRate, summary.LoopsPassed, summary.LoopsEvaluated)
+	fmt.Fprintf(file, "Assertion Check Pass Rate: %.4f (%d/%d checks)\n",
+		summary.CheckPassRate, summary.ChecksPassed, summary.Checks)
+	if len(summary.LoopsSkipped) > 0 {
+		fmt.Fprintf(file, "Assertion Skipped Loops (no response): %v\n", summary.LoopsSkipped)
+	}
+	for _, failure := range summary.Failures {
+		fmt.Fprintf(file, "  %s: %d/%d failed %v\n",
+			failure.Assertion, failure.Failed, failure.Evaluated, failure.Loops)
+	}
+	for _, res := range summary.Results {
+		if !res.Passed {
+			fmt.Fprintf(file, "  Loop %d %s: %s\n", res.Loop, res.Assertion, res.Message)
+		}
+	}
+	fmt.Fprintf(file, "\n")
+}
+
+func saveIntervalToFile(file *os.File, name string, ci *analysis.ConfidenceInterval) {
+	if ci == nil {
+		return
 	}
+	fmt.Fprintf(file, "  %s: %.4f [%.4f, %.4f] at %.0f%%, loops needed %d (have %d)\n",
+		name, ci.Estimate, ci.Lower, ci.Upper, ci.Confidence*100, ci.LoopsNeeded, ci.Samples)
 }
 
 func printDualAgentDebugOutput(entries []analysis.LogEntry) {
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"agent-reliability-tests/pkg/analysis"
+)
+
+// isTrendRequest reports whether the arguments name more than a single log
+// file: several files, a directory or a glob pattern
+func isTrendRequest(args []string) bool {
+	if len(args) > 1 {
+		return true
+	}
+	if info, err := os.Stat(args[0]); err == nil {
+		return info.IsDir()
+	}
+	return strings.ContainsAny(args[0], "*?[")
+}
+
+// runTrendAnalysis analyzes every matching log and reports metrics over time
+// per agent and template
+func runTrendAnalysis(args []string) {
+	files, err := analysis.ExpandLogPaths(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(files) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: no log files found")
+		os.Exit(1)
+	}
+
+	opts, err := buildAnalysisOptions()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var write func(io.Writer, *analysis.Trend) error
+	switch outputFormat {
+	case "text":
+	case "csv":
+		write = analysis.WriteTrendCSV
+	case "json":
+		write = analysis.WriteTrendJSON
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown trend format %q (expected text, csv or json)\n", outputFormat)
+		os.Exit(1)
+	}
+
+	fmt.Fprintf(os.Stderr, "Analyzing %d log files...\n", len(files))
+	trend, err := analysis.AnalyzeTrend(files, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error analyzing logs: %v\n", err)
+		os.Exit(1)
+	}
+	for _, skip := range trend.Skipped {
+		fmt.Fprintf(os.Stderr, "Warning: skipped %s: %s\n", skip.File, skip.Error)
+	}
+
+	if write == nil {
+		analysis.PrintTrendSummary(trend)
+		if outputFile == "" {
+			return
+		}
+		// The text summary is saved alongside as CSV for further processing
+		write = analysis.WriteTrendCSV
+	}
+
+	if outputFile == "" {
+		if err := write(os.Stdout, trend); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing trend: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := writeOutputFile(outputFile, func(w io.Writer) error {
+		return write(w, trend)
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing trend: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "Trend saved to: %s\n", outputFile)
+}
@@ -10,12 +10,16 @@ import (
 )
 
 var (
-	loops        int
-	filename     string
-	parallel     bool
-	batchSize    int
-	queue        int
+	loops          int
+	filename       string
+	parallel       bool
+	batchSize      int
+	queue          int
 	promptTemplate string
+	adaptive       bool
+	minLoops       int
+	targetMargin   float64
+	checkEvery     int
 )
 
 func main() {
@@ -33,9 +37,14 @@ func main() {
 	rootCmd.Flags().IntVar(&batchSize, "batch", 5, "Number of parallel executions to run at once (default: 5, only used with --parallel)")
 	rootCmd.Flags().IntVarP(&queue, "queue", "q", 0, "Number of worker threads for queue mode (default: 1, mutually exclusive with --parallel)")
 	rootCmd.Flags().StringVar(&promptTemplate, "prompt", "", "Path to Go template file for custom prompts (if not provided, uses default prompt)")
+	rootCmd.Flags().BoolVar(&adaptive, "adaptive", false, "Stop dispatching once the reliability estimate converges (--loops becomes the maximum, queue mode only)")
+	rootCmd.Flags().IntVar(&minLoops, "min-loops", reliability.DefaultMinLoops, "Loops to complete before checking convergence (only used with --adaptive)")
+	rootCmd.Flags().Float64Var(&targetMargin, "margin", reliability.DefaultTargetMargin, "Target margin of error on the majority-cluster share (only used with --adaptive)")
+	rootCmd.Flags().IntVar(&checkEvery, "check-every", reliability.DefaultCheckEvery, "Completed loops between convergence checks (only used with --adaptive)")
 
 	// Make --parallel and --queue mutually exclusive
 	rootCmd.MarkFlagsMutuallyExclusive("parallel", "queue")
+	rootCmd.MarkFlagsMutuallyExclusive("parallel", "adaptive")
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
@@ -52,6 +61,10 @@ func runTest(cmd *cobra.Command, args []string) {
 		BatchSize:      batchSize,
 		Queue:          queue,
 		PromptTemplate: promptTemplate,
+		Adaptive:       adaptive,
+		MinLoops:       minLoops,
+		TargetMargin:   targetMargin,
+		CheckEvery:     checkEvery,
 	}
 
 	result, err := reliability.RunReliabilityTest(config)
@@ -62,5 +75,9 @@ func runTest(cmd *cobra.Command, args []string) {
 
 	fmt.Printf("Test completed successfully!\n")
 	fmt.Printf("Results saved to: %s\n", result.OutputFile)
+	fmt.Printf("Loops run: %d\n", result.LoopsRun)
+	if adaptive {
+		fmt.Printf("Converged: %v\n", result.Converged)
+	}
 	fmt.Printf("Total duration: %v\n", result.Duration)
 }
@@ -5,15 +5,63 @@ import (
 	"strings"
 )
 
+// AnalysisOptions configures the analysis performed on a log file
+type AnalysisOptions struct {
+	Clustering ClusterOptions
+	Outliers   OutlierOptions
+	Assertions []Assertion // expected-output checks, none by default
+	Scoring    ScoringPolicy
+	Bootstrap  BootstrapOptions
+	Metric     SimilarityMetric // nil uses the lexical metric
+	Code       CodeOptions
+	Relay      RelayOptions
+	Plan       PlanOptions
+	Behaviour  []BehaviourRule // checked in order; nil uses the default rules
+	Answers    AnswerOptions
+
+	// Constraints are format rules every response must follow; nil loads the
+	// constraints file next to the log's prompt template, if any
+	Constraints []FormatConstraint
+
+	// ExpectedAgent is the subagent every loop should delegate to, defaulting
+	// to the agent in the log header
+	ExpectedAgent string
+
+	// Judge grades every response against JudgeReference when both are set
+	Judge          *Judge
+	JudgeReference string
+}
+
+// DefaultAnalysisOptions returns the options used by AnalyzeLogFile
+func DefaultAnalysisOptions() AnalysisOptions {
+	return AnalysisOptions{
+		Clustering: DefaultClusterOptions(),
+		Outliers:   DefaultOutlierOptions(),
+		Scoring:    DefaultScoringPolicy(),
+		Bootstrap:  DefaultBootstrapOptions(),
+		Metric:     LexicalMetric{},
+		Code:       DefaultCodeOptions(),
+		Relay:      DefaultRelayOptions(),
+		Plan:       DefaultPlanOptions(),
+		Behaviour:  DefaultBehaviourRules(),
+		Answers:    DefaultAnswerOptions(),
+	}
+}
+
 // AnalyzeLogFile performs comprehensive dual agent analysis on a log file
 func AnalyzeLogFile(filename string) (*DualAgentAnalysisResult, error) {
-	entries, err := ParseLogFile(filename)
+	return AnalyzeLogFileWithOptions(filename, DefaultAnalysisOptions())
+}
+
+// AnalyzeLogFileWithOptions performs dual agent analysis using the given options
+func AnalyzeLogFileWithOptions(filename string, opts AnalysisOptions) (*DualAgentAnalysisResult, error) {
+	entries, metadata, warnings, err := ParseLogFileWithWarnings(filename)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse log file: %w", err)
 	}
 
 	if len(entries) == 0 {
-		return &DualAgentAnalysisResult{}, nil
+		return &DualAgentAnalysisResult{Metadata: metadata, Warnings: warnings}, nil
 	}
 
 	// Extract responses for both agents
@@ -32,82 +80,210 @@ func AnalyzeLogFile(filename string) (*DualAgentAnalysisResult, error) {
 	// Analyze Main Agent responses
 	var mainAnalysis *AnalysisResult
 	if len(mainResponses) > 0 {
-		mainAnalysis = analyzeResponses(mainResponses, entries, "main")
+		mainAnalysis, err = analyzeResponses(mainResponses, entries, "main", opts)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// Analyze Sub Agent responses
 	var subAnalysis *AnalysisResult
 	if len(subResponses) > 0 {
-		subAnalysis = analyzeResponses(subResponses, entries, "sub")
+		subAnalysis, err = analyzeResponses(subResponses, entries, "sub", opts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Check correctness against the expected outputs
+	assertions := EvaluateAssertions(entries, opts.Assertions)
+	if assertions != nil {
+		if mainAnalysis != nil {
+			mainAnalysis.Assertions = assertions.Main
+		}
+		if subAnalysis != nil {
+			subAnalysis.Assertions = assertions.Sub
+		}
+	}
+
+	// Check that responses follow the format the prompt asked for
+	constraints := opts.Constraints
+	if constraints == nil {
+		constraints, err = LoadTemplateConstraints(metadata.Template)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load template constraints: %w", err)
+		}
+	}
+	if mainAnalysis != nil {
+		mainAnalysis.Constraints = CheckConstraints(entries, constraints, TargetMain)
+	}
+	if subAnalysis != nil {
+		subAnalysis.Constraints = CheckConstraints(entries, constraints, TargetSub)
+	}
+
+	// Label what each response did: answered, refused, asked a question...
+	behaviourRules := opts.Behaviour
+	if behaviourRules == nil {
+		behaviourRules = DefaultBehaviourRules()
+	}
+	if mainAnalysis != nil {
+		mainAnalysis.Behaviour = ClassifyBehaviour(entries, TargetMain, behaviourRules)
+	}
+	if subAnalysis != nil {
+		subAnalysis.Behaviour = ClassifyBehaviour(entries, TargetSub, behaviourRules)
+	}
+
+	// Grade responses against the reference answer
+	if opts.Judge != nil && opts.JudgeReference != "" {
+		for _, agent := range []*AnalysisResult{mainAnalysis, subAnalysis} {
+			if agent == nil {
+				continue
+			}
+			agent.Judgments, err = JudgeAgainstReference(opts.Judge, agent, opts.JudgeReference)
+			if err != nil {
+				return nil, fmt.Errorf("failed to judge responses: %w", err)
+			}
+		}
+	}
+
+	// Grade each agent and estimate uncertainty once every metric is available
+	if mainAnalysis != nil {
+		mainAnalysis.Score = ScoreReliability(mainAnalysis, opts.Scoring)
+		mainAnalysis.Intervals = ComputeIntervals(mainAnalysis, opts.Bootstrap)
+	}
+	if subAnalysis != nil {
+		subAnalysis.Score = ScoreReliability(subAnalysis, opts.Scoring)
+		subAnalysis.Intervals = ComputeIntervals(subAnalysis, opts.Bootstrap)
+	}
+
+	expected := opts.ExpectedAgent
+	if expected == "" {
+		expected = metadata.Agent
 	}
 
 	return &DualAgentAnalysisResult{
+		Metadata:           metadata,
+		Warnings:           warnings,
 		TotalEntries:       len(entries),
 		MainAgentAnalysis:  mainAnalysis,
 		SubAgentAnalysis:   subAnalysis,
 		MainAgentResponses: mainResponses,
 		SubAgentResponses:  subResponses,
 		Entries:            entries,
+		Assertions:         assertions,
+		Latency:            ComputeLatencyStats(entries),
+		Errors:             SummarizeErrors(entries),
+		Delegation:         SummarizeDelegation(entries, expected),
+		Relay:              AnalyzeRelay(entries, opts.Relay),
 	}, nil
 }
 
 // analyzeResponses performs analysis on a set of responses
-func analyzeResponses(responses []string, allEntries []LogEntry, agentType string) *AnalysisResult {
+func analyzeResponses(responses []string, allEntries []LogEntry, agentType string, opts AnalysisOptions) (*AnalysisResult, error) {
 	if len(responses) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	// Calculate similarity matrix
-	matrix := CalculateSimilarityMatrix(responses)
+	metric := opts.Metric
+	if metric == nil {
+		metric = LexicalMetric{}
+	}
+	matrix, err := metric.Matrix(responses)
+	if err != nil {
+		return nil, fmt.Errorf("%s agent %s similarity: %w", agentType, metric.Name(), err)
+	}
 	avgSimilarity := FindAverageSimilarity(matrix)
 
-	// Find most abnormal response - create entries that properly represent the responses being analyzed
-	responseEntries := make([]LogEntry, len(responses))
-	for i, response := range responses {
-		// Find the entry that contains this response and create a properly structured entry
-		for _, entry := range allEntries {
-			var matchFound bool
-			if agentType == "main" && entry.MainAgentResponse == response {
-				matchFound = true
-			} else if agentType == "sub" && entry.SubAgentResponse == response {
-				matchFound = true
-			}
-
-			if matchFound {
-				// Create an entry that properly represents which agent we're analyzing
-				responseEntries[i] = LogEntry{
-					Loop:              entry.Loop,
-					Timestamp:         entry.Timestamp,
-					Prompt:            entry.Prompt,
-					MainAgentResponse: response, // Store the response we're analyzing as MainAgentResponse for consistency
-					SubAgentResponse:  "",       // Clear the other to avoid confusion
-					RawResponse:       response,
-					Errors:            entry.Errors,
-					ExecutionTime:     entry.ExecutionTime,
-				}
-				break
-			}
+	// Create entries that properly represent the responses being analyzed.
+	// Responses were collected from allEntries in order, so the entries with a
+	// response for this agent line up with the responses by position.
+	responseEntries := make([]LogEntry, 0, len(responses))
+	for _, entry := range allEntries {
+		response := entry.SubAgentResponse
+		if agentType == "main" {
+			response = entry.MainAgentResponse
+		}
+		if response == "" {
+			continue
 		}
+
+		// Store the response we're analyzing as MainAgentResponse for consistency
+		responseEntries = append(responseEntries, LogEntry{
+			Loop:              entry.Loop,
+			Timestamp:         entry.Timestamp,
+			Prompt:            entry.Prompt,
+			MainAgentResponse: response,
+			SubAgentResponse:  "", // Clear the other to avoid confusion
+			RawResponse:       response,
+			Errors:            entry.Errors,
+			ExecutionTime:     entry.ExecutionTime,
+		})
 	}
 
 	mostAbnormal, abnormalityScore := FindMostAbnormal(responseEntries, matrix)
 
-	// Cluster responses (threshold of 0.7 for similarity)
-	clusters := ClusterResponses(responses, matrix, 0.7)
+	// Cluster responses and score how well separated the clusters are
+	clusters := ClusterResponsesWith(responses, matrix, opts.Clustering)
+	_, silhouette := Silhouette(matrix, clusterGroups(clusters))
 
 	// Find most common pattern
 	mostCommonPattern, mostCommonCount := findMostCommonPattern(responses, clusters)
 
-	return &AnalysisResult{
+	// Score every response and flag the statistical outliers
+	anomalyScores, outliers := DetectOutliers(responseEntries, matrix, opts.Outliers)
+
+	// Diff flagged responses against the dominant pattern
+	for i := range outliers {
+		if reference := diffReference(outliers[i].Index, clusters); reference >= 0 {
+			diff := DiffResponses(responses[reference], responses[outliers[i].Index])
+			outliers[i].Diff = &diff
+			outliers[i].ReferenceLoop = responseEntries[reference].Loop
+		}
+	}
+	var abnormalDiff *ResponseDiff
+	abnormalReference := 0
+	for i, entry := range responseEntries {
+		if entry.Loop != mostAbnormal.Loop {
+			continue
+		}
+		if reference := diffReference(i, clusters); reference >= 0 {
+			diff := DiffResponses(responses[reference], responses[i])
+			abnormalDiff = &diff
+			abnormalReference = responseEntries[reference].Loop
+		}
+		break
+	}
+
+	result := &AnalysisResult{
 		TotalResponses:    len(responses),
+		Metric:            metric.Name(),
 		AverageSimilarity: avgSimilarity,
 		MostCommonPattern: mostCommonPattern,
 		MostCommonCount:   mostCommonCount,
 		MostAbnormal:      mostAbnormal,
 		AbnormalityScore:  abnormalityScore,
+		AbnormalDiff:      abnormalDiff,
+		AbnormalReference: abnormalReference,
 		SimilarityMatrix:  matrix,
 		Clusters:          clusters,
+		ClusterMethod:     opts.Clustering.Method,
+		Silhouette:        silhouette,
+		ResponseEntries:   responseEntries,
+		AnomalyScores:     anomalyScores,
+		Outliers:          outliers,
+		OutlierMethod:     opts.Outliers.Method,
+		OutlierThreshold:  opts.Outliers.EffectiveThreshold(),
+	}
+	result.Latency = CorrelateLatency(result)
+	result.Code = AnalyzeCode(responseEntries, opts.Code)
+	result.Fields = AnalyzeJSONFields(responseEntries)
+	result.Answers = AnalyzeAnswers(responseEntries, opts.Answers)
+	if opts.Plan.Enabled {
+		result.Plan = AnalyzePlans(responseEntries, opts.Plan)
 	}
+
+	return result, nil
 }
 
 // findMostCommonPattern identifies the most frequent response pattern
@@ -119,12 +295,9 @@ func findMostCommonPattern(responses []string, clusters []ResponseCluster) (stri
 	// The largest cluster represents the most common pattern
 	largestCluster := clusters[0]
 
-	// Use the first response in the cluster as the representative pattern
-	if len(largestCluster.Responses) > 0 {
-		patternIndex := largestCluster.Responses[0]
-		if patternIndex < len(responses) {
-			return responses[patternIndex], largestCluster.Size
-		}
+	// Use the medoid of the cluster as the representative pattern
+	if len(largestCluster.Responses) > 0 && largestCluster.Medoid < len(responses) {
+		return responses[largestCluster.Medoid], largestCluster.Size
 	}
 
 	return "", 0
@@ -137,6 +310,30 @@ func PrintDualAgentAnalysisResult(result *DualAgentAnalysisResult) {
 	fmt.Printf("Main Agent Responses: %d\n", len(result.MainAgentResponses))
 	fmt.Printf("Sub Agent Responses: %d\n", len(result.SubAgentResponses))
 
+	if len(result.Warnings) > 0 {
+		fmt.Printf("\n--- PARSE WARNINGS (%d) ---\n", len(result.Warnings))
+		for i, warning := range result.Warnings {
+			if i == 10 {
+				fmt.Printf("... and %d more\n", len(result.Warnings)-i)
+				break
+			}
+			fmt.Println(warning)
+		}
+	}
+
+	if result.Latency != nil {
+		printLatencyStats(result.Latency)
+	}
+	if result.Errors != nil {
+		printErrorSummary(result.Errors)
+	}
+	if result.Delegation != nil {
+		printDelegationSummary(result.Delegation)
+	}
+	if result.Relay != nil {
+		printRelaySummary(result.Relay)
+	}
+
 	// Print Main Agent Analysis
 	if result.MainAgentAnalysis != nil {
 		fmt.Println("\n" + strings.Repeat("=", 60))
@@ -146,6 +343,9 @@ func PrintDualAgentAnalysisResult(result *DualAgentAnalysisResult) {
 	} else {
 		fmt.Println("\n--- MAIN AGENT ANALYSIS ---")
 		fmt.Println("No main agent responses found")
+		if result.Assertions != nil && result.Assertions.Main != nil {
+			printAssertionSummary(result.Assertions.Main)
+		}
 	}
 
 	// Print Sub Agent Analysis
@@ -157,16 +357,28 @@ func PrintDualAgentAnalysisResult(result *DualAgentAnalysisResult) {
 	} else {
 		fmt.Println("\n--- SUB AGENT ANALYSIS ---")
 		fmt.Println("No sub agent responses found")
+		if result.Assertions != nil && result.Assertions.Sub != nil {
+			printAssertionSummary(result.Assertions.Sub)
+		}
 	}
 }
 
 // printSingleAgentAnalysis prints analysis for a single agent
 func printSingleAgentAnalysis(result *AnalysisResult, agentName string) {
 	fmt.Printf("Total Responses: %d\n", result.TotalResponses)
-	fmt.Printf("Average Similarity: %.3f (%.1f%%)\n", result.AverageSimilarity, result.AverageSimilarity*100)
+	fmt.Printf("Average Similarity: %.3f (%.1f%%, %s)\n", result.AverageSimilarity, result.AverageSimilarity*100, result.Metric)
+	if result.Answers != nil && result.Answers.Extracted > 0 {
+		fmt.Printf("Answer Agreement: %.3f (%d/%d answers, entropy %.3f bits)\n",
+			result.Answers.Agreement, result.Answers.MajorityCount, result.Answers.Extracted, result.Answers.Entropy)
+	}
+	if result.Constraints != nil {
+		fmt.Printf("Format Compliance: %.3f (%d/%d loops)\n",
+			result.Constraints.ComplianceRate, result.Constraints.LoopsCompliant, result.Constraints.LoopsEvaluated)
+	}
 
 	fmt.Println("\n--- CLUSTERING ANALYSIS ---")
-	fmt.Printf("Found %d distinct response clusters\n", len(result.Clusters))
+	fmt.Printf("Found %d distinct response clusters (%s, silhouette %.3f)\n",
+		len(result.Clusters), result.ClusterMethod, result.Silhouette)
 
 	for i, cluster := range result.Clusters {
 		if i >= 3 { // Only show top 3 clusters
@@ -196,11 +408,379 @@ func printSingleAgentAnalysis(result *AnalysisResult, agentName string) {
 		fmt.Println("No significantly abnormal responses found")
 	}
 
+	fmt.Printf("\n--- OUTLIERS (%s, threshold %.2f) ---\n", result.OutlierMethod, result.OutlierThreshold)
+	if len(result.Outliers) > 0 {
+		for _, outlier := range result.Outliers {
+			fmt.Printf("Loop %d: score %.3f, mean similarity %.3f\n",
+				outlier.Entry.Loop, outlier.Score, outlier.MeanSimilarity)
+			if outlier.NearestNormal >= 0 {
+				fmt.Printf("  Nearest normal: loop %d (similarity %.3f)\n",
+					outlier.NearestNormalLoop, outlier.NearestNormalSimilarity)
+			} else {
+				fmt.Println("  Nearest normal: none")
+			}
+			fmt.Printf("  Response: \"%s\"\n", truncateString(getResponseFromEntry(outlier.Entry), 100))
+		}
+	} else {
+		fmt.Println("No outliers flagged")
+	}
+
+	if result.Assertions != nil {
+		printAssertionSummary(result.Assertions)
+	}
+
+	if result.Answers != nil {
+		printAnswerSummary(result.Answers)
+	}
+
+	if result.Constraints != nil {
+		printConstraintSummary(result.Constraints)
+	}
+
+	if result.Behaviour != nil {
+		printBehaviourSummary(result.Behaviour)
+	}
+
+	if result.Code != nil {
+		printCodeSummary(result.Code)
+	}
+
+	if result.Fields != nil {
+		printFieldSummary(result.Fields)
+	}
+
+	if result.Plan != nil {
+		printPlanSummary(result.Plan)
+	}
+
+	if result.Judgments != nil {
+		printJudgeSummary(result.Judgments)
+	}
+
+	if result.Latency != nil {
+		printLatencyCorrelation(result.Latency)
+	}
+
+	if result.Intervals != nil {
+		printIntervals(result.Intervals)
+	}
+
 	fmt.Println("\n--- RELIABILITY ASSESSMENT ---")
+	if result.Score != nil {
+		fmt.Printf("Composite Score: %.3f\n", result.Score.Composite)
+		for _, c := range result.Score.Components {
+			fmt.Printf("  %-17s value %.3f  score %.3f  weight %.2f  contribution %.3f\n",
+				c.Metric, c.Value, c.Score, c.Weight, c.Contribution)
+		}
+		fmt.Printf("Limiting Metric: %s\n", result.Score.Explanation)
+	}
 	reliability := assessReliability(result)
 	fmt.Printf("%s Reliability: %s\n", agentName, reliability)
 }
 
+// printLatencyStats prints the distribution of execution times
+func printLatencyStats(stats *LatencyStats) {
+	fmt.Printf("\n--- LATENCY (%d timed loops) ---\n", stats.Samples)
+	fmt.Printf("Min %.2fs  Median %.2fs  P90 %.2fs  P99 %.2fs  Max %.2fs (loop %d)\n",
+		stats.Min, stats.Median, stats.P90, stats.P99, stats.Max, stats.SlowestLoop)
+	fmt.Printf("Mean %.2fs  Std Dev %.2fs  Coefficient of Variation %.3f\n", stats.Mean, stats.StdDev, stats.CV)
+}
+
+// printErrorSummary prints stderr error classes and empty responses
+func printErrorSummary(summary *ErrorSummary) {
+	fmt.Println("\n--- ERRORS ---")
+	fmt.Printf("Loops with errors: %d/%d (%.1f%%)\n",
+		summary.LoopsWithErrors, summary.Entries, summary.ErrorRate*100)
+	for _, class := range summary.Classes {
+		fmt.Printf("  %-11s %d loops, %d messages (loops %v)\n", class.Class, len(class.Loops), class.Messages, class.Loops)
+		fmt.Printf("              e.g. \"%s\"\n", truncateString(class.Example, 80))
+	}
+	if len(summary.EmptyResponseLoops) > 0 {
+		fmt.Printf("Empty responses: %d loops %v\n", len(summary.EmptyResponseLoops), summary.EmptyResponseLoops)
+	}
+}
+
+// printAnswerSummary prints the distribution of extracted final answers
+func printAnswerSummary(summary *AnswerSummary) {
+	fmt.Println("\n--- ANSWER VOTE ---")
+	fmt.Printf("Extractors: %s\n", strings.Join(summary.Extractors, ", "))
+	fmt.Printf("Answers extracted: %d/%d\n", summary.Extracted, summary.Responses)
+	if summary.Extracted > 0 {
+		fmt.Printf("Majority answer: \"%s\" (%d/%d, %.1f%%)\n",
+			truncateString(summary.Majority, 60), summary.MajorityCount, summary.Extracted, summary.Agreement*100)
+		fmt.Printf("Entropy: %.3f bits over %d distinct answers\n", summary.Entropy, len(summary.Distribution))
+		for i, count := range summary.Distribution {
+			if i == 10 {
+				fmt.Printf("... and %d more\n", len(summary.Distribution)-i)
+				break
+			}
+			fmt.Printf("  %-30s %d loops (%.1f%%) %v\n", "\""+truncateString(count.Answer, 28)+"\"", len(count.Loops), count.Share*100, count.Loops)
+		}
+	}
+	if len(summary.DeviantLoops) > 0 {
+		fmt.Printf("Deviating loops: %v\n", summary.DeviantLoops)
+	}
+	if len(summary.NoAnswerLoops) > 0 {
+		fmt.Printf("No answer found: loops %v\n", summary.NoAnswerLoops)
+	}
+}
+
+// printConstraintSummary prints which format constraints were violated and
+// where
+func printConstraintSummary(summary *ConstraintSummary) {
+	fmt.Println("\n--- FORMAT CONSTRAINTS ---")
+	fmt.Printf("Compliance Rate: %d/%d loops (%.1f%%)\n",
+		summary.LoopsCompliant, summary.LoopsEvaluated, summary.ComplianceRate*100)
+	for _, violation := range summary.Violations {
+		if violation.Violated == 0 {
+			fmt.Printf("  PASS %s\n", violation.Constraint)
+			continue
+		}
+		fmt.Printf("  FAIL %s: %d/%d violated (loops %v)\n",
+			violation.Constraint, violation.Violated, violation.Evaluated, violation.Loops)
+	}
+	for _, loop := range summary.Loops {
+		for _, message := range loop.Violations {
+			fmt.Printf("  Loop %d: %s\n", loop.Loop, truncateString(message, 100))
+		}
+	}
+}
+
+// printBehaviourSummary prints the distribution of behaviour labels
+func printBehaviourSummary(summary *BehaviourSummary) {
+	fmt.Println("\n--- BEHAVIOUR ---")
+	fmt.Printf("Answered: %.1f%% of %d loops\n", summary.AnsweredRate*100, summary.Classified)
+	for _, count := range summary.Labels {
+		fmt.Printf("  %-22s %d loops (%.1f%%)", count.Label, len(count.Loops), count.Rate*100)
+		if count.Label != BehaviourAnswered {
+			fmt.Printf(" %v", count.Loops)
+		}
+		fmt.Println()
+	}
+}
+
+// printCodeSummary prints the code block checks and code/prose similarity
+func printCodeSummary(summary *CodeSummary) {
+	fmt.Println("\n--- CODE BLOCKS ---")
+	fmt.Printf("Loops with code: %d/%d\n", summary.LoopsWithCode, summary.Responses)
+	for _, language := range summary.Languages {
+		fmt.Printf("  %-10s %d blocks in %d loops\n", language.Language, language.Blocks, language.Loops)
+	}
+	fmt.Printf("Code similarity: %.3f  Prose similarity: %.3f\n", summary.CodeSimilarity, summary.ProseSimilarity)
+	if summary.GoBlocks == 0 {
+		return
+	}
+	fmt.Printf("Go blocks: %d parse, %d gofmt clean", summary.GoParsed, summary.GoFormatted)
+	if summary.GoVetted > 0 {
+		fmt.Printf(", %d/%d vet clean", summary.GoVetClean, summary.GoVetted)
+	}
+	fmt.Printf(" (of %d)\n", summary.GoBlocks)
+	for _, loop := range summary.Loops {
+		for _, check := range loop.Go {
+			fmt.Printf("  Loop %d block %d: %s\n", loop.Loop, check.Block+1, truncateString(check.Status(), 100))
+		}
+	}
+}
+
+// printFieldSummary prints the consistency of each JSON field, least
+// consistent first
+func printFieldSummary(summary *FieldSummary) {
+	fmt.Println("\n--- JSON FIELDS ---")
+	fmt.Printf("JSON responses: %d/%d, same fields in %.1f%%\n",
+		summary.JSONLoops, summary.Responses, summary.StructureAgreement*100)
+	fmt.Printf("Flaky fields: %d/%d, mean consistency %.3f\n",
+		summary.FlakyFields, len(summary.Fields), summary.MeanConsistency)
+	for i, field := range summary.Fields {
+		if i == 15 {
+			fmt.Printf("... and %d more\n", len(summary.Fields)-i)
+			break
+		}
+		fmt.Printf("  %-30s %.3f  present %.0f%%, %s %.0f%%", truncateString(field.Path, 30),
+			field.Consistency, field.PresenceRate*100, field.Kind, field.TypeStability*100)
+		switch field.Kind {
+		case "object":
+		case "array":
+			fmt.Printf(", overlap %.3f", field.SetOverlap)
+		default:
+			fmt.Printf(", agreement %.0f%% (%d values, majority %s)",
+				field.ValueAgreement*100, field.DistinctValues, truncateString(field.MajorityValue, 30))
+		}
+		fmt.Println()
+	}
+	if len(summary.NonJSONLoops) > 0 {
+		fmt.Printf("No JSON: loops %v\n", summary.NonJSONLoops)
+	}
+}
+
+// printPlanSummary prints the consistency of plan structure across loops
+func printPlanSummary(summary *PlanSummary) {
+	fmt.Println("\n--- PLAN STRUCTURE ---")
+	fmt.Printf("Loops with a plan: %d\n", summary.LoopsWithPlan)
+	fmt.Printf("Steps: mean %.1f, std dev %.2f (CV %.3f), range %d-%d\n",
+		summary.MeanSteps, summary.StepStdDev, summary.StepCV, summary.MinSteps, summary.MaxSteps)
+	fmt.Printf("Step alignment: %.3f  Agent agreement: %.3f\n", summary.MeanAlignment, summary.AgentAgreement)
+	fmt.Printf("Reference plan (loop %d):\n", summary.ReferenceLoop)
+	for i, step := range summary.ReferenceSteps {
+		agent := step.Agent
+		if agent == "" {
+			agent = "unassigned"
+		}
+		fmt.Printf("  %d. %s [%s]\n", i+1, truncateString(step.Title, 60), agent)
+	}
+	for _, agent := range summary.AgentAssignments {
+		fmt.Printf("  %-24s %d steps in %d loops\n", agent.Agent, agent.Calls, agent.Loops)
+	}
+	for _, loop := range summary.Loops {
+		if len(loop.Missing) == 0 && len(loop.Extra) == 0 && len(loop.Reassigned) == 0 {
+			continue
+		}
+		fmt.Printf("  Loop %d: alignment %.2f, %d missing, %d extra, %d reassigned\n",
+			loop.Loop, loop.Alignment, len(loop.Missing), len(loop.Extra), len(loop.Reassigned))
+	}
+	if len(summary.LoopsWithoutPlan) > 0 {
+		fmt.Printf("No plan found: loops %v\n", summary.LoopsWithoutPlan)
+	}
+}
+
+// printJudgeSummary prints how many responses the judge found equivalent to
+// the reference answer
+func printJudgeSummary(summary *JudgeSummary) {
+	fmt.Println("\n--- JUDGE VS REFERENCE ---")
+	fmt.Printf("Equivalent: %d/%d (%.1f%%), mean score %.3f\n",
+		summary.Equivalent, summary.Judged, summary.Rate*100, summary.MeanScore)
+	for _, judged := range summary.Results {
+		if judged.Verdict.Equivalent {
+			continue
+		}
+		fmt.Printf("  Loop %d: score %.2f - %s\n", judged.Loop, judged.Verdict.Score, truncateString(judged.Verdict.Reason, 100))
+	}
+}
+
+// printDelegationSummary prints whether loops called the expected subagent
+func printDelegationSummary(summary *DelegationSummary) {
+	fmt.Println("\n--- DELEGATION ---")
+	expected := summary.Expected
+	if expected == "" {
+		expected = "any subagent"
+	}
+	fmt.Printf("Called %s: %d/%d traced loops (%.1f%%)\n",
+		expected, summary.LoopsInvoked, summary.TracedLoops, summary.InvocationRate*100)
+	if summary.UntracedLoops > 0 {
+		fmt.Printf("Loops without a tool trace: %d\n", summary.UntracedLoops)
+	}
+	for _, agent := range summary.Agents {
+		fmt.Printf("  %-24s %d calls in %d loops\n", agent.Agent, agent.Calls, agent.Loops)
+	}
+	if len(summary.NoCallLoops) > 0 {
+		fmt.Printf("No subagent call (answered directly): loops %v\n", summary.NoCallLoops)
+	}
+	if len(summary.MismatchLoops) > 0 {
+		fmt.Printf("Called a different agent: loops %v\n", summary.MismatchLoops)
+	}
+	if len(summary.RepeatedCallLoops) > 0 {
+		fmt.Printf("Called %s more than once: loops %v\n", expected, summary.RepeatedCallLoops)
+	}
+}
+
+// printRelaySummary prints how faithfully instructions were relayed
+func printRelaySummary(summary *RelaySummary) {
+	fmt.Println("\n--- RELAY FIDELITY ---")
+	fmt.Printf("Faithful relays: %d/%d (%.1f%%)\n", summary.Faithful, summary.Checked, summary.FaithfulRate*100)
+	fmt.Printf("Mean instruction coverage %.3f, drift %.3f, reply coverage %.3f\n",
+		summary.MeanCoverage, summary.MeanDrift, summary.MeanReplyCoverage)
+	if len(summary.Dropped) > 0 {
+		fmt.Printf("Most dropped terms: %s\n", formatTermCounts(summary.Dropped, 5))
+	}
+	if len(summary.Added) > 0 {
+		fmt.Printf("Most added terms: %s\n", formatTermCounts(summary.Added, 5))
+	}
+	for _, check := range summary.Loops {
+		if check.Faithful && len(check.AddedInstructions) == 0 {
+			continue
+		}
+		fmt.Printf("  Loop %d: coverage %.2f, drift %.2f\n", check.Loop, check.Coverage, check.Drift)
+		for _, clause := range check.DroppedInstructions {
+			fmt.Printf("    dropped: \"%s\"\n", truncateString(clause, 80))
+		}
+		for _, literal := range check.DroppedLiterals {
+			fmt.Printf("    dropped literal: \"%s\"\n", truncateString(literal, 80))
+		}
+		for _, clause := range check.AddedInstructions {
+			fmt.Printf("    added: \"%s\"\n", truncateString(clause, 80))
+		}
+	}
+	if len(summary.SkippedLoops) > 0 {
+		fmt.Printf("No relayed message reported: loops %v\n", summary.SkippedLoops)
+	}
+}
+
+// formatTermCounts lists the first n terms with their loop counts
+func formatTermCounts(terms []TermCount, n int) string {
+	var parts []string
+	for i, term := range terms {
+		if i == n {
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%s (%d)", term.Term, term.Loops))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// printLatencyCorrelation prints how latency relates to an agent's responses
+func printLatencyCorrelation(correlation *LatencyCorrelation) {
+	fmt.Println("\n--- LATENCY CORRELATION ---")
+	fmt.Printf("Response length: Pearson %.3f, Spearman %.3f\n",
+		correlation.LengthPearson, correlation.LengthSpearman)
+	fmt.Printf("Cluster membership explains %.1f%% of latency variance\n", correlation.ClusterEffect*100)
+	for _, cluster := range correlation.Clusters {
+		fmt.Printf("  Cluster %d: median %.2fs, mean %.2fs (%d timed)\n",
+			cluster.Cluster, cluster.Median, cluster.Mean, cluster.Samples)
+	}
+}
+
+// printIntervals prints bootstrap confidence intervals and loops-needed estimates
+func printIntervals(intervals *ReliabilityIntervals) {
+	fmt.Printf("\n--- CONFIDENCE INTERVALS (%d resamples) ---\n", intervals.Iterations)
+	printInterval("Average Similarity", intervals.AverageSimilarity, intervals.TargetMargin)
+	printInterval("Majority Share", intervals.MajorityShare, intervals.TargetMargin)
+	printInterval("Pass Rate", intervals.PassRate, intervals.TargetMargin)
+}
+
+// printInterval prints a single confidence interval
+func printInterval(name string, ci *ConfidenceInterval, targetMargin float64) {
+	if ci == nil {
+		return
+	}
+	fmt.Printf("%s: %.3f (%.0f%% CI %.3f-%.3f, ±%.3f)\n",
+		name, ci.Estimate, ci.Confidence*100, ci.Lower, ci.Upper, ci.Margin())
+	if ci.LoopsNeeded > ci.Samples {
+		fmt.Printf("  ~%d loops needed for ±%.3f (have %d)\n", ci.LoopsNeeded, targetMargin, ci.Samples)
+	} else {
+		fmt.Printf("  Within ±%.3f with %d loops\n", targetMargin, ci.Samples)
+	}
+}
+
+// printAssertionSummary prints the correctness assertion results for an agent
+func printAssertionSummary(summary *AssertionSummary) {
+	fmt.Println("\n--- CORRECTNESS ASSERTIONS ---")
+	fmt.Printf("Pass Rate: %d/%d loops (%.1f%%)\n",
+		summary.LoopsPassed, summary.LoopsEvaluated, summary.PassRate*100)
+	fmt.Printf("Checks Passed: %d/%d (%.1f%%)\n",
+		summary.ChecksPassed, summary.Checks, summary.CheckPassRate*100)
+	if len(summary.LoopsSkipped) > 0 {
+		fmt.Printf("Skipped (no response): %d loops %v\n", len(summary.LoopsSkipped), summary.LoopsSkipped)
+	}
+
+	for _, failure := range summary.Failures {
+		if failure.Failed == 0 {
+			fmt.Printf("  PASS %s\n", failure.Assertion)
+			continue
+		}
+		fmt.Printf("  FAIL %s: %d/%d failed (loops %v)\n",
+			failure.Assertion, failure.Failed, failure.Evaluated, failure.Loops)
+	}
+}
+
 // getResponseFromEntry extracts the appropriate response from a log entry
 func getResponseFromEntry(entry LogEntry) string {
 	if entry.MainAgentResponse != "" {
@@ -212,23 +792,19 @@ func getResponseFromEntry(entry LogEntry) string {
 	return entry.RawResponse
 }
 
-// assessReliability provides a qualitative assessment based on metrics
+// assessReliability provides a qualitative assessment based on the scoring policy
 func assessReliability(result *AnalysisResult) string {
-	avgSim := result.AverageSimilarity
-	abnormality := result.AbnormalityScore
-	consistency := float64(result.MostCommonCount) / float64(result.TotalResponses)
-
-	if avgSim >= 0.9 && abnormality <= 0.2 && consistency >= 0.8 {
-		return "EXCELLENT - Highly consistent responses"
-	} else if avgSim >= 0.7 && abnormality <= 0.4 && consistency >= 0.6 {
-		return "GOOD - Generally consistent with minor variations"
-	} else if avgSim >= 0.5 && abnormality <= 0.6 && consistency >= 0.4 {
-		return "MODERATE - Some inconsistency present"
-	} else if avgSim >= 0.3 && abnormality <= 0.8 {
-		return "POOR - Significant inconsistencies detected"
-	} else {
-		return "VERY POOR - Highly unreliable responses"
+	score := result.Score
+	if score == nil {
+		score = ScoreReliability(result, DefaultScoringPolicy())
+	}
+	if score == nil {
+		return "UNGRADED - No responses to score"
+	}
+	if score.Description == "" {
+		return score.Grade
 	}
+	return score.Grade + " - " + score.Description
 }
 
 // truncateString truncates a string to maxLen with ellipsis
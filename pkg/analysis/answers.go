@@ -0,0 +1,269 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// AnswerExtractorKind identifies how an answer is pulled out of a response
+type AnswerExtractorKind string
+
+const (
+	AnswerRegex      AnswerExtractorKind = "regex"       // first capture group, or the whole match
+	AnswerLastLine   AnswerExtractorKind = "last-line"   // last non-empty line outside code fences
+	AnswerLastNumber AnswerExtractorKind = "last-number" // last number in the response
+	AnswerJSON       AnswerExtractorKind = "json"        // field at a dotted path of the response's JSON
+)
+
+var (
+	answerMarkupRegex = regexp.MustCompile(`^(?:[-*+>]\s+|\d+[.)]\s+|#{1,6}\s+)+|\*\*|` + "`")
+	whitespaceRegex   = regexp.MustCompile(`\s+`)
+)
+
+// AnswerExtractor pulls the final answer out of a response
+type AnswerExtractor struct {
+	Spec    string // as given, e.g. "json:result.answer"
+	Kind    AnswerExtractorKind
+	Pattern *regexp.Regexp // regex
+	Path    string         // json
+}
+
+// ParseAnswerExtractor parses an extractor spec: regex:<pattern>, last-line,
+// last-number or json:<path>
+func ParseAnswerExtractor(spec string) (AnswerExtractor, error) {
+	extractor := AnswerExtractor{Spec: spec}
+	kind, argument, _ := strings.Cut(spec, ":")
+	extractor.Kind = AnswerExtractorKind(strings.ToLower(strings.TrimSpace(kind)))
+
+	switch extractor.Kind {
+	case AnswerLastLine, AnswerLastNumber:
+		if argument != "" {
+			return extractor, fmt.Errorf("answer extractor %q: %s takes no argument", spec, extractor.Kind)
+		}
+	case AnswerRegex:
+		pattern, err := regexp.Compile(argument)
+		if err != nil {
+			return extractor, fmt.Errorf("answer extractor %q: invalid regex: %w", spec, err)
+		}
+		extractor.Pattern = pattern
+	case AnswerJSON:
+		extractor.Path = strings.TrimPrefix(strings.TrimSpace(argument), rootFieldPath+".")
+		if extractor.Path == "" {
+			return extractor, fmt.Errorf("answer extractor %q: json needs a field path", spec)
+		}
+	default:
+		return extractor, fmt.Errorf("unknown answer extractor %q (expected regex:<pattern>, last-line, last-number or json:<path>)", spec)
+	}
+	return extractor, nil
+}
+
+// Extract returns the answer in a response
+func (e AnswerExtractor) Extract(response string) (string, bool) {
+	switch e.Kind {
+	case AnswerRegex:
+		matches := e.Pattern.FindStringSubmatch(response)
+		if matches == nil {
+			return "", false
+		}
+		if len(matches) > 1 {
+			return matches[1], true
+		}
+		return matches[0], true
+	case AnswerLastLine:
+		lines := strings.Split(StripCodeBlocks(response), "\n")
+		for i := len(lines) - 1; i >= 0; i-- {
+			if line := strings.TrimSpace(answerMarkupRegex.ReplaceAllString(strings.TrimSpace(lines[i]), "")); line != "" {
+				return line, true
+			}
+		}
+	case AnswerLastNumber:
+		numbers := numberRegex.FindAllString(response, -1)
+		if len(numbers) > 0 {
+			return numbers[len(numbers)-1], true
+		}
+	case AnswerJSON:
+		document, ok := ExtractJSON(response)
+		if !ok {
+			return "", false
+		}
+		value, ok := jsonPathValue(document, e.Path)
+		if !ok {
+			return "", false
+		}
+		if text, isString := value.(string); isString {
+			return text, true
+		}
+		return canonicalJSON(value), true
+	}
+	return "", false
+}
+
+// AnswerOptions configures answer extraction
+type AnswerOptions struct {
+	Extractors    []AnswerExtractor // tried in order until one finds an answer; none disables extraction
+	CaseSensitive bool
+}
+
+// DefaultAnswerOptions returns options with answer extraction disabled
+func DefaultAnswerOptions() AnswerOptions {
+	return AnswerOptions{}
+}
+
+// LoopAnswer is the answer extracted from one loop's response
+type LoopAnswer struct {
+	Loop      int
+	Raw       string // as extracted
+	Answer    string // normalized for comparison
+	Extractor string // spec of the extractor that found it
+}
+
+// AnswerCount is how many loops gave one answer
+type AnswerCount struct {
+	Answer string
+	Loops  []int
+	Share  float64 // of loops with an answer
+}
+
+// AnswerSummary is the self-consistency of the extracted final answers,
+// independent of the surrounding prose
+type AnswerSummary struct {
+	Extractors     []string
+	Responses      int
+	Extracted      int
+	ExtractionRate float64
+	NoAnswerLoops  []int
+	Majority       string
+	MajorityCount  int
+	Agreement      float64 // share of extracted answers equal to the majority
+	Entropy        float64 // Shannon entropy of the answer distribution, in bits
+	Distribution   []AnswerCount
+	DeviantLoops   []int // loops with an answer other than the majority
+	Loops          []LoopAnswer
+}
+
+// AnalyzeAnswers extracts the final answer of every response and votes on
+// the majority, returning nil when no extractor is configured
+func AnalyzeAnswers(entries []LogEntry, opts AnswerOptions) *AnswerSummary {
+	if len(opts.Extractors) == 0 {
+		return nil
+	}
+
+	summary := &AnswerSummary{Responses: len(entries)}
+	for _, extractor := range opts.Extractors {
+		summary.Extractors = append(summary.Extractors, extractor.Spec)
+	}
+	counts := make(map[string]*AnswerCount)
+
+	for _, entry := range entries {
+		response := getResponseFromEntry(entry)
+		found := false
+		for _, extractor := range opts.Extractors {
+			raw, ok := extractor.Extract(response)
+			if !ok {
+				continue
+			}
+			answer := normalizeAnswer(raw, opts.CaseSensitive)
+			if answer == "" {
+				continue
+			}
+			summary.Loops = append(summary.Loops, LoopAnswer{
+				Loop:      entry.Loop,
+				Raw:       strings.TrimSpace(raw),
+				Answer:    answer,
+				Extractor: extractor.Spec,
+			})
+			count, ok := counts[answer]
+			if !ok {
+				count = &AnswerCount{Answer: answer}
+				counts[answer] = count
+			}
+			count.Loops = append(count.Loops, entry.Loop)
+			found = true
+			break
+		}
+		if !found {
+			summary.NoAnswerLoops = append(summary.NoAnswerLoops, entry.Loop)
+		}
+	}
+	summary.Extracted = len(summary.Loops)
+	if summary.Responses > 0 {
+		summary.ExtractionRate = float64(summary.Extracted) / float64(summary.Responses)
+	}
+	if summary.Extracted == 0 {
+		return summary
+	}
+
+	n := float64(summary.Extracted)
+	for _, count := range counts {
+		count.Share = float64(len(count.Loops)) / n
+		summary.Entropy -= count.Share * math.Log2(count.Share)
+		summary.Distribution = append(summary.Distribution, *count)
+	}
+	sort.Slice(summary.Distribution, func(i, j int) bool {
+		if len(summary.Distribution[i].Loops) != len(summary.Distribution[j].Loops) {
+			return len(summary.Distribution[i].Loops) > len(summary.Distribution[j].Loops)
+		}
+		return summary.Distribution[i].Answer < summary.Distribution[j].Answer
+	})
+	// Guard against -0 for a unanimous answer
+	summary.Entropy = math.Abs(summary.Entropy)
+
+	majority := summary.Distribution[0]
+	summary.Majority = majority.Answer
+	summary.MajorityCount = len(majority.Loops)
+	summary.Agreement = majority.Share
+	for _, loop := range summary.Loops {
+		if loop.Answer != summary.Majority {
+			summary.DeviantLoops = append(summary.DeviantLoops, loop.Loop)
+		}
+	}
+	return summary
+}
+
+// normalizeAnswer makes equivalent answers compare equal: whitespace is
+// collapsed, surrounding quotes, emphasis and trailing punctuation dropped,
+// numbers written canonically and, unless case sensitive, text lowercased
+func normalizeAnswer(answer string, caseSensitive bool) string {
+	answer = whitespaceRegex.ReplaceAllString(strings.TrimSpace(answer), " ")
+	for trimmed := ""; trimmed != answer; {
+		trimmed = answer
+		answer = strings.TrimRight(answer, ".!;:,")
+		answer = strings.TrimSpace(strings.Trim(answer, "\"'“”‘’`*_"))
+	}
+
+	if number, err := strconv.ParseFloat(strings.ReplaceAll(answer, ",", ""), 64); err == nil {
+		return strconv.FormatFloat(number, 'f', -1, 64)
+	}
+	if !caseSensitive {
+		answer = strings.ToLower(answer)
+	}
+	return answer
+}
+
+// jsonPathValue follows a dotted path through decoded JSON, where numeric
+// segments index arrays
+func jsonPathValue(value interface{}, path string) (interface{}, bool) {
+	for _, segment := range strings.Split(path, ".") {
+		switch node := value.(type) {
+		case map[string]interface{}:
+			child, ok := node[segment]
+			if !ok {
+				return nil, false
+			}
+			value = child
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, false
+			}
+			value = node[index]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
@@ -0,0 +1,411 @@
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// AssertionType identifies the check an assertion performs
+type AssertionType string
+
+const (
+	AssertExact       AssertionType = "exact"
+	AssertContains    AssertionType = "contains"
+	AssertNotContains AssertionType = "not_contains"
+	AssertRegex       AssertionType = "regex"
+	AssertJSONSchema  AssertionType = "json_schema"
+	AssertNumeric     AssertionType = "numeric"
+)
+
+// Assertion targets
+const (
+	TargetMain = "main"
+	TargetSub  = "sub"
+	TargetBoth = "both"
+)
+
+// Assertion is a single expected-output check loaded from an assertions file
+type Assertion struct {
+	Name       string          `json:"name"`
+	Type       AssertionType   `json:"type"`
+	Target     string          `json:"target"`      // main, sub or both (default)
+	Value      string          `json:"value"`       // text for exact/contains/not_contains, pattern for regex
+	IgnoreCase bool            `json:"ignore_case"` // case-insensitive text and regex matching
+	Schema     json.RawMessage `json:"schema"`      // json_schema: schema the response must satisfy
+	Expected   float64         `json:"expected"`    // numeric: expected value
+	Tolerance  float64         `json:"tolerance"`   // numeric: allowed absolute difference
+
+	pattern *regexp.Regexp
+	schema  map[string]interface{}
+}
+
+// AssertionsFile is the on-disk format of an assertions file
+type AssertionsFile struct {
+	Assertions []Assertion `json:"assertions"`
+}
+
+// AssertionResult is the outcome of one assertion against one response
+type AssertionResult struct {
+	Loop      int
+	Agent     string
+	Assertion string
+	Passed    bool
+	Message   string
+}
+
+// AssertionFailureCount summarises the failures of a single assertion
+type AssertionFailureCount struct {
+	Assertion string
+	Evaluated int
+	Failed    int
+	Loops     []int // loops where the assertion failed
+}
+
+// AssertionSummary reports how one agent's responses fared against the assertions
+type AssertionSummary struct {
+	Agent          string
+	LoopsEvaluated int
+	LoopsSkipped   []int // loops without a response from this agent
+	LoopsPassed    int
+	PassRate       float64 // share of loops passing every assertion
+	Checks         int
+	ChecksPassed   int
+	CheckPassRate  float64 // share of individual checks that passed
+	Failures       []AssertionFailureCount
+	Results        []AssertionResult
+}
+
+// AssertionReport holds assertion summaries for both agents
+type AssertionReport struct {
+	Main *AssertionSummary
+	Sub  *AssertionSummary
+}
+
+var numberRegex = regexp.MustCompile(`-?\d+(?:,\d{3})*(?:\.\d+)?(?:[eE][-+]?\d+)?`)
+
+// LoadAssertions reads and validates an assertions file
+func LoadAssertions(filename string) ([]Assertion, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read assertions file: %w", err)
+	}
+
+	var file AssertionsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse assertions file: %w", err)
+	}
+
+	for i := range file.Assertions {
+		if err := file.Assertions[i].compile(i); err != nil {
+			return nil, err
+		}
+	}
+
+	return file.Assertions, nil
+}
+
+// compile validates an assertion and prepares its pattern or schema
+func (a *Assertion) compile(index int) error {
+	if a.Name == "" {
+		a.Name = fmt.Sprintf("%s #%d", a.Type, index+1)
+	}
+
+	switch strings.ToLower(a.Target) {
+	case "", TargetBoth:
+		a.Target = TargetBoth
+	case TargetMain, TargetSub:
+		a.Target = strings.ToLower(a.Target)
+	default:
+		return fmt.Errorf("assertion %q: unknown target %q (expected main, sub or both)", a.Name, a.Target)
+	}
+
+	switch a.Type {
+	case AssertExact, AssertContains, AssertNotContains, AssertNumeric:
+	case AssertRegex:
+		pattern := a.Value
+		if a.IgnoreCase {
+			pattern = "(?i)" + pattern
+		}
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("assertion %q: invalid regex: %w", a.Name, err)
+		}
+		a.pattern = compiled
+	case AssertJSONSchema:
+		if len(a.Schema) > 0 {
+			if err := json.Unmarshal(a.Schema, &a.schema); err != nil {
+				return fmt.Errorf("assertion %q: invalid schema: %w", a.Name, err)
+			}
+		}
+	default:
+		return fmt.Errorf("assertion %q: unknown type %q", a.Name, a.Type)
+	}
+
+	return nil
+}
+
+// appliesTo reports whether the assertion checks the given agent's response
+func (a Assertion) appliesTo(agent string) bool {
+	return a.Target == TargetBoth || a.Target == agent
+}
+
+// Check evaluates the assertion against a response
+func (a Assertion) Check(response string) (bool, string) {
+	if strings.TrimSpace(response) == "" {
+		return false, "empty response"
+	}
+
+	text, value := response, a.Value
+	if a.IgnoreCase {
+		text, value = strings.ToLower(text), strings.ToLower(value)
+	}
+
+	switch a.Type {
+	case AssertExact:
+		if strings.TrimSpace(text) == strings.TrimSpace(value) {
+			return true, ""
+		}
+		return false, fmt.Sprintf("expected exactly %q", a.Value)
+	case AssertContains:
+		if strings.Contains(text, value) {
+			return true, ""
+		}
+		return false, fmt.Sprintf("missing %q", a.Value)
+	case AssertNotContains:
+		if !strings.Contains(text, value) {
+			return true, ""
+		}
+		return false, fmt.Sprintf("contains forbidden %q", a.Value)
+	case AssertRegex:
+		if a.pattern != nil && a.pattern.MatchString(response) {
+			return true, ""
+		}
+		return false, fmt.Sprintf("no match for /%s/", a.Value)
+	case AssertJSONSchema:
+		document, ok := ExtractJSON(response)
+		if !ok {
+			return false, "no valid JSON found"
+		}
+		if err := validateJSONSchema(document, a.schema, "$"); err != nil {
+			return false, err.Error()
+		}
+		return true, ""
+	case AssertNumeric:
+		numbers := numberRegex.FindAllString(response, -1)
+		for _, match := range numbers {
+			number, err := strconv.ParseFloat(strings.ReplaceAll(match, ",", ""), 64)
+			if err == nil && math.Abs(number-a.Expected) <= a.Tolerance {
+				return true, ""
+			}
+		}
+		if len(numbers) == 0 {
+			return false, "no number found"
+		}
+		return false, fmt.Sprintf("no number within %g of %g", a.Tolerance, a.Expected)
+	}
+
+	return false, fmt.Sprintf("unknown assertion type %q", a.Type)
+}
+
+// EvaluateAssertions checks every entry's main and sub agent responses.
+// Loops where an agent gave no response are skipped for that agent rather
+// than failed, as for format constraints and behaviour labels.
+func EvaluateAssertions(entries []LogEntry, assertions []Assertion) *AssertionReport {
+	if len(assertions) == 0 {
+		return nil
+	}
+
+	return &AssertionReport{
+		Main: evaluateAgentAssertions(entries, assertions, TargetMain),
+		Sub:  evaluateAgentAssertions(entries, assertions, TargetSub),
+	}
+}
+
+// evaluateAgentAssertions checks one agent's responses, returning nil when no
+// assertion targets that agent
+func evaluateAgentAssertions(entries []LogEntry, assertions []Assertion, agent string) *AssertionSummary {
+	var applicable []Assertion
+	for _, assertion := range assertions {
+		if assertion.appliesTo(agent) {
+			applicable = append(applicable, assertion)
+		}
+	}
+	if len(applicable) == 0 {
+		return nil
+	}
+
+	summary := &AssertionSummary{Agent: agent}
+	failures := make([]AssertionFailureCount, len(applicable))
+	for i, assertion := range applicable {
+		failures[i].Assertion = assertion.Name
+	}
+
+	for _, entry := range entries {
+		response := entry.SubAgentResponse
+		if agent == TargetMain {
+			response = entry.MainAgentResponse
+		}
+		if strings.TrimSpace(response) == "" {
+			summary.LoopsSkipped = append(summary.LoopsSkipped, entry.Loop)
+			continue
+		}
+
+		loopPassed := true
+		for i, assertion := range applicable {
+			passed, message := assertion.Check(response)
+			summary.Results = append(summary.Results, AssertionResult{
+				Loop:      entry.Loop,
+				Agent:     agent,
+				Assertion: assertion.Name,
+				Passed:    passed,
+				Message:   message,
+			})
+
+			summary.Checks++
+			failures[i].Evaluated++
+			if passed {
+				summary.ChecksPassed++
+			} else {
+				loopPassed = false
+				failures[i].Failed++
+				failures[i].Loops = append(failures[i].Loops, entry.Loop)
+			}
+		}
+
+		summary.LoopsEvaluated++
+		if loopPassed {
+			summary.LoopsPassed++
+		}
+	}
+
+	if summary.LoopsEvaluated > 0 {
+		summary.PassRate = float64(summary.LoopsPassed) / float64(summary.LoopsEvaluated)
+	}
+	if summary.Checks > 0 {
+		summary.CheckPassRate = float64(summary.ChecksPassed) / float64(summary.Checks)
+	}
+
+	// Most frequently failing assertions first
+	sort.SliceStable(failures, func(i, j int) bool {
+		return failures[i].Failed > failures[j].Failed
+	})
+	summary.Failures = failures
+
+	return summary
+}
+
+// validateJSONSchema checks a decoded JSON value against a subset of JSON
+// Schema: type, enum, required, properties, additionalProperties, items,
+// minimum/maximum, minLength/maxLength, minItems/maxItems and pattern
+func validateJSONSchema(value interface{}, schema map[string]interface{}, path string) error {
+	if schema == nil {
+		return nil
+	}
+
+	if expected, ok := schema["type"]; ok && !matchesSchemaType(value, expected) {
+		return fmt.Errorf("%s: expected type %v, got %s", path, expected, jsonTypeName(value))
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, option := range enum {
+			if fmt.Sprint(option) == fmt.Sprint(value) && jsonTypeName(option) == jsonTypeName(value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: value %v not in enum", path, value)
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, key := range required {
+				if _, present := v[fmt.Sprint(key)]; !present {
+					return fmt.Errorf("%s: missing required property %q", path, key)
+				}
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		for key, child := range v {
+			childSchema, known := properties[key].(map[string]interface{})
+			if !known {
+				if allowed, ok := schema["additionalProperties"].(bool); ok && !allowed {
+					return fmt.Errorf("%s: unexpected property %q", path, key)
+				}
+				continue
+			}
+			if err := validateJSONSchema(child, childSchema, path+"."+key); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if minItems, ok := schema["minItems"].(float64); ok && float64(len(v)) < minItems {
+			return fmt.Errorf("%s: expected at least %g items, got %d", path, minItems, len(v))
+		}
+		if maxItems, ok := schema["maxItems"].(float64); ok && float64(len(v)) > maxItems {
+			return fmt.Errorf("%s: expected at most %g items, got %d", path, maxItems, len(v))
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				if err := validateJSONSchema(item, items, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case string:
+		if minLength, ok := schema["minLength"].(float64); ok && float64(len(v)) < minLength {
+			return fmt.Errorf("%s: shorter than %g characters", path, minLength)
+		}
+		if maxLength, ok := schema["maxLength"].(float64); ok && float64(len(v)) > maxLength {
+			return fmt.Errorf("%s: longer than %g characters", path, maxLength)
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("%s: invalid schema pattern: %v", path, err)
+			}
+			if !re.MatchString(v) {
+				return fmt.Errorf("%s: does not match pattern %q", path, pattern)
+			}
+		}
+	case float64:
+		if minimum, ok := schema["minimum"].(float64); ok && v < minimum {
+			return fmt.Errorf("%s: %g is below minimum %g", path, v, minimum)
+		}
+		if maximum, ok := schema["maximum"].(float64); ok && v > maximum {
+			return fmt.Errorf("%s: %g is above maximum %g", path, v, maximum)
+		}
+	}
+
+	return nil
+}
+
+// matchesSchemaType checks a value against a schema type or list of types
+func matchesSchemaType(value interface{}, expected interface{}) bool {
+	var types []string
+	switch t := expected.(type) {
+	case string:
+		types = []string{t}
+	case []interface{}:
+		for _, name := range t {
+			types = append(types, fmt.Sprint(name))
+		}
+	}
+
+	actual := jsonTypeName(value)
+	for _, name := range types {
+		if name == actual || (name == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
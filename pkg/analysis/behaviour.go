@@ -0,0 +1,331 @@
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// BehaviourLabel is what a response did, independent of what it said
+type BehaviourLabel string
+
+const (
+	BehaviourAnswered             BehaviourLabel = "answered"
+	BehaviourRefused              BehaviourLabel = "refused"
+	BehaviourClarification        BehaviourLabel = "clarification"         // asked a question instead of answering
+	BehaviourUnverifiedDelegation BehaviourLabel = "unverified_delegation" // claimed to delegate with no subagent call in the trace
+	BehaviourTruncated            BehaviourLabel = "truncated"
+	BehaviourErrored              BehaviourLabel = "errored"
+)
+
+// Fields a behaviour rule pattern can match
+const (
+	BehaviourFieldResponse = "response"
+	BehaviourFieldErrors   = "errors"
+	BehaviourFieldPrompt   = "prompt"
+)
+
+// BehaviourRule labels a response when every condition it sets holds
+type BehaviourRule struct {
+	Name      string         `json:"name"`
+	Label     BehaviourLabel `json:"label"`
+	Target    string         `json:"target"`    // main, sub or both (default)
+	Field     string         `json:"field"`     // text the pattern matches: response (default), errors or prompt
+	Pattern   string         `json:"pattern"`   // regex, case-insensitive
+	Empty     *bool          `json:"empty"`     // the loop produced no output at all
+	Truncated *bool          `json:"truncated"` // the response ends inside a code block or mid-sentence
+	Question  *bool          `json:"question"`  // the response ends with a question
+	Delegated *bool          `json:"delegated"` // the tool trace shows a subagent call; untraced loops never match
+	MaxWords  int            `json:"max_words"` // the response has at most this many words, 0 for any length
+
+	pattern *regexp.Regexp
+}
+
+// BehaviourRulesFile is the on-disk format of a behaviour rules file. Its
+// rules are checked before the defaults unless they replace them.
+type BehaviourRulesFile struct {
+	Rules           []BehaviourRule `json:"rules"`
+	ReplaceDefaults bool            `json:"replace_defaults"`
+}
+
+// BehaviourCount is how many loops received one label
+type BehaviourCount struct {
+	Label BehaviourLabel
+	Loops []int
+	Rate  float64
+}
+
+// LoopBehaviour is the label of one loop's response and the rule that gave it
+type LoopBehaviour struct {
+	Loop  int
+	Label BehaviourLabel
+	Rule  string // empty for the answered fallback
+}
+
+// BehaviourSummary is the distribution of behaviour labels for one agent
+type BehaviourSummary struct {
+	Agent        string
+	Classified   int
+	AnsweredRate float64
+	Labels       []BehaviourCount // most frequent first
+	Loops        []LoopBehaviour
+}
+
+var (
+	refusalPattern       = `^(?:i'?m sorry,? but |sorry,? )?(?:i (?:can'?t|cannot|won'?t|will not|am unable to|am not able to|must decline to)|i'?m (?:unable|not able) to)\b.{0,80}\b(?:help|assist|comply|do (?:that|this)|provide|answer|complete|fulfil+)`
+	clarificationPattern = `\b(?:could|can|would) you (?:please )?(?:clarify|specify|confirm|provide|share|tell me|let me know)|what (?:exactly )?do you mean|which (?:\w+ )?(?:do|did|would|should) you|do you want me to|would you like me to|before i (?:proceed|start|continue)|more (?:details|context|information) (?:about|on)`
+	delegationPattern    = `\bi (?:have |'ve )?(?:delegated|asked|told|instructed|sent|passed|handed|forwarded|invoked|called|used)\b.{0,40}\bagent\b|\b(?:sub)?agent (?:said|replied|responded|returned|reported|answered)\b`
+	errorOutputPattern   = `^\s*(?:error|api error|execution error)\b`
+)
+
+// DefaultBehaviourRules returns the built-in rules in the order they are
+// checked. Responses matching none of them are answered.
+func DefaultBehaviourRules() []BehaviourRule {
+	yes, no := true, false
+	rules := []BehaviourRule{
+		{Name: "no output", Label: BehaviourErrored, Empty: &yes},
+		{Name: "error message", Label: BehaviourErrored, Pattern: errorOutputPattern, MaxWords: 60},
+		{Name: "cut off", Label: BehaviourTruncated, Truncated: &yes},
+		{Name: "refusal", Label: BehaviourRefused, Pattern: refusalPattern, MaxWords: 150},
+		{Name: "clarifying question", Label: BehaviourClarification, Pattern: clarificationPattern, Question: &yes, MaxWords: 120},
+		{Name: "claimed delegation", Label: BehaviourUnverifiedDelegation, Target: TargetMain, Pattern: delegationPattern, Delegated: &no},
+		{Name: "reply without call", Label: BehaviourUnverifiedDelegation, Target: TargetSub, Delegated: &no},
+	}
+	for i := range rules {
+		if err := rules[i].compile(i); err != nil {
+			panic(err)
+		}
+	}
+	return rules
+}
+
+// LoadBehaviourRules reads a behaviour rules file and returns its rules
+// followed by the defaults, unless the file replaces them
+func LoadBehaviourRules(filename string) ([]BehaviourRule, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read behaviour rules file: %w", err)
+	}
+
+	var file BehaviourRulesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse behaviour rules file: %w", err)
+	}
+
+	for i := range file.Rules {
+		if err := file.Rules[i].compile(i); err != nil {
+			return nil, err
+		}
+	}
+
+	if file.ReplaceDefaults {
+		return file.Rules, nil
+	}
+	return append(file.Rules, DefaultBehaviourRules()...), nil
+}
+
+// compile validates a rule and prepares its pattern
+func (r *BehaviourRule) compile(index int) error {
+	if r.Label == "" {
+		return fmt.Errorf("behaviour rule #%d: missing label", index+1)
+	}
+	if r.Name == "" {
+		r.Name = fmt.Sprintf("%s #%d", r.Label, index+1)
+	}
+
+	switch strings.ToLower(r.Target) {
+	case "", TargetBoth:
+		r.Target = TargetBoth
+	case TargetMain, TargetSub:
+		r.Target = strings.ToLower(r.Target)
+	default:
+		return fmt.Errorf("behaviour rule %q: unknown target %q (expected main, sub or both)", r.Name, r.Target)
+	}
+
+	switch strings.ToLower(r.Field) {
+	case "", BehaviourFieldResponse:
+		r.Field = BehaviourFieldResponse
+	case BehaviourFieldErrors, BehaviourFieldPrompt:
+		r.Field = strings.ToLower(r.Field)
+	default:
+		return fmt.Errorf("behaviour rule %q: unknown field %q (expected response, errors or prompt)", r.Name, r.Field)
+	}
+
+	if r.Pattern != "" {
+		compiled, err := regexp.Compile("(?is)" + r.Pattern)
+		if err != nil {
+			return fmt.Errorf("behaviour rule %q: invalid regex: %w", r.Name, err)
+		}
+		r.pattern = compiled
+	}
+
+	if r.pattern == nil && r.Empty == nil && r.Truncated == nil && r.Question == nil && r.Delegated == nil && r.MaxWords == 0 {
+		return fmt.Errorf("behaviour rule %q: no conditions", r.Name)
+	}
+	return nil
+}
+
+// matches reports whether every condition of the rule holds for a response
+func (r BehaviourRule) matches(entry LogEntry, agent, response string) bool {
+	if r.Target != TargetBoth && r.Target != agent {
+		return false
+	}
+	if r.Empty != nil && *r.Empty != (strings.TrimSpace(entry.RawResponse) == "" && strings.TrimSpace(response) == "") {
+		return false
+	}
+	if r.Truncated != nil && *r.Truncated != looksTruncated(response) {
+		return false
+	}
+	if r.Question != nil && *r.Question != endsWithQuestion(response) {
+		return false
+	}
+	if r.Delegated != nil {
+		if !entry.ToolTrace || *r.Delegated != hasSubagentCall(entry.ToolCalls) {
+			return false
+		}
+	}
+	if r.MaxWords > 0 && len(strings.Fields(response)) > r.MaxWords {
+		return false
+	}
+	if r.pattern != nil {
+		text := response
+		switch r.Field {
+		case BehaviourFieldErrors:
+			text = entry.Errors
+		case BehaviourFieldPrompt:
+			text = entry.Prompt
+		}
+		if !r.pattern.MatchString(strings.TrimSpace(text)) {
+			return false
+		}
+	}
+	return true
+}
+
+// ClassifyEntry labels one agent's response in a loop with the first
+// matching rule, falling back to answered
+func ClassifyEntry(entry LogEntry, agent string, rules []BehaviourRule) LoopBehaviour {
+	response := entry.MainAgentResponse
+	if agent == TargetSub {
+		response = entry.SubAgentResponse
+	}
+
+	for _, rule := range rules {
+		if rule.matches(entry, agent, response) {
+			return LoopBehaviour{Loop: entry.Loop, Label: rule.Label, Rule: rule.Name}
+		}
+	}
+	return LoopBehaviour{Loop: entry.Loop, Label: BehaviourAnswered}
+}
+
+// ClassifyBehaviour labels an agent's response in every loop. Loops where
+// the agent said nothing but the other did are skipped; loops with no output
+// at all are classified, so errored runs count against both agents. It
+// returns nil when no loop was classified.
+func ClassifyBehaviour(entries []LogEntry, agent string, rules []BehaviourRule) *BehaviourSummary {
+	summary := &BehaviourSummary{Agent: agent}
+	counts := make(map[BehaviourLabel]*BehaviourCount)
+
+	for _, entry := range entries {
+		response := entry.MainAgentResponse
+		if agent == TargetSub {
+			response = entry.SubAgentResponse
+		}
+		if strings.TrimSpace(response) == "" && strings.TrimSpace(entry.RawResponse) != "" {
+			continue
+		}
+
+		loop := ClassifyEntry(entry, agent, rules)
+		summary.Loops = append(summary.Loops, loop)
+		summary.Classified++
+
+		count, ok := counts[loop.Label]
+		if !ok {
+			count = &BehaviourCount{Label: loop.Label}
+			counts[loop.Label] = count
+		}
+		count.Loops = append(count.Loops, entry.Loop)
+	}
+	if summary.Classified == 0 {
+		return nil
+	}
+
+	for _, count := range counts {
+		count.Rate = float64(len(count.Loops)) / float64(summary.Classified)
+		summary.Labels = append(summary.Labels, *count)
+	}
+	sort.Slice(summary.Labels, func(i, j int) bool {
+		if len(summary.Labels[i].Loops) != len(summary.Labels[j].Loops) {
+			return len(summary.Labels[i].Loops) > len(summary.Labels[j].Loops)
+		}
+		return summary.Labels[i].Label < summary.Labels[j].Label
+	})
+	if answered, ok := counts[BehaviourAnswered]; ok {
+		summary.AnsweredRate = answered.Rate
+	}
+	return summary
+}
+
+// completedAnsweredRate is the share of loops that did not error which were
+// answered. Errored loops are left out as error_rate already scores them.
+func (s *BehaviourSummary) completedAnsweredRate() (float64, bool) {
+	if s == nil {
+		return 0, false
+	}
+	completed, answered := 0, 0
+	for _, loop := range s.Loops {
+		switch loop.Label {
+		case BehaviourErrored:
+			continue
+		case BehaviourAnswered:
+			answered++
+		}
+		completed++
+	}
+	if completed == 0 {
+		return 0, false
+	}
+	return float64(answered) / float64(completed), true
+}
+
+// looksTruncated reports whether a response stops inside a code block or
+// mid-sentence, on a trailing comma, colon or open bracket
+func looksTruncated(response string) bool {
+	inBlock := false
+	for _, line := range strings.Split(response, "\n") {
+		switch {
+		case !inBlock && fenceOpenRegex.MatchString(line):
+			inBlock = true
+		case inBlock && fenceCloseRegex.MatchString(line):
+			inBlock = false
+		}
+	}
+	if inBlock {
+		return true
+	}
+
+	trimmed := strings.TrimSpace(response)
+	if trimmed == "" {
+		return false
+	}
+	return strings.ContainsAny(trimmed[len(trimmed)-1:], ",:([{")
+}
+
+// endsWithQuestion reports whether the last line of a response is a question
+func endsWithQuestion(response string) bool {
+	trimmed := strings.TrimRight(strings.TrimSpace(response), "*_ ")
+	return strings.HasSuffix(trimmed, "?")
+}
+
+// hasSubagentCall reports whether the main agent delegated to a subagent
+func hasSubagentCall(calls []ToolCall) bool {
+	for _, call := range calls {
+		if call.IsSubagentCall() && call.Parent == "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,243 @@
+package analysis
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// BootstrapOptions configures bootstrap confidence intervals
+type BootstrapOptions struct {
+	Iterations   int     // number of resamples, 0 disables intervals
+	Confidence   float64 // confidence level, e.g. 0.95
+	TargetMargin float64 // margin of error used for the loops-needed estimate
+	Seed         int64   // seed for reproducible resampling
+}
+
+// DefaultBootstrapOptions returns the options used by the analyzer
+func DefaultBootstrapOptions() BootstrapOptions {
+	return BootstrapOptions{
+		Iterations:   1000,
+		Confidence:   0.95,
+		TargetMargin: 0.05,
+		Seed:         1,
+	}
+}
+
+// ConfidenceInterval is a percentile bootstrap interval around an estimate
+type ConfidenceInterval struct {
+	Estimate    float64
+	Lower       float64
+	Upper       float64
+	Confidence  float64
+	Samples     int // number of loops the estimate is based on
+	LoopsNeeded int // estimated loops for the target margin of error
+}
+
+// Margin returns the half-width of the interval
+func (ci ConfidenceInterval) Margin() float64 {
+	return (ci.Upper - ci.Lower) / 2
+}
+
+// ReliabilityIntervals holds confidence intervals for the headline metrics
+type ReliabilityIntervals struct {
+	AverageSimilarity *ConfidenceInterval
+	MajorityShare     *ConfidenceInterval
+	PassRate          *ConfidenceInterval // nil without assertions
+	TargetMargin      float64
+	Iterations        int
+}
+
+// ComputeIntervals bootstraps confidence intervals for an agent's analysis
+func ComputeIntervals(result *AnalysisResult, opts BootstrapOptions) *ReliabilityIntervals {
+	if result == nil || opts.Iterations <= 0 || result.TotalResponses < 2 {
+		return nil
+	}
+
+	intervals := &ReliabilityIntervals{
+		AverageSimilarity: BootstrapAverageSimilarity(result.SimilarityMatrix, opts),
+		MajorityShare:     BootstrapProportion(dominantMembership(result.Clusters, result.TotalResponses), opts),
+		TargetMargin:      opts.TargetMargin,
+		Iterations:        opts.Iterations,
+	}
+	if result.Assertions != nil {
+		intervals.PassRate = BootstrapProportion(loopPassed(result.Assertions), opts)
+	}
+	return intervals
+}
+
+// BootstrapAverageSimilarity resamples responses with replacement and
+// recomputes the average pairwise similarity. Pairs that draw the same
+// response twice are skipped so duplicates do not inflate the estimate.
+func BootstrapAverageSimilarity(matrix [][]float64, opts BootstrapOptions) *ConfidenceInterval {
+	n := len(matrix)
+	if n < 2 || opts.Iterations <= 0 {
+		return nil
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	sample := make([]int, n)
+	stats := make([]float64, 0, opts.Iterations)
+
+	for iter := 0; iter < opts.Iterations; iter++ {
+		for i := range sample {
+			sample[i] = rng.Intn(n)
+		}
+
+		total := 0.0
+		count := 0
+		for a := 0; a < n; a++ {
+			for b := a + 1; b < n; b++ {
+				if sample[a] != sample[b] {
+					total += matrix[sample[a]][sample[b]]
+					count++
+				}
+			}
+		}
+		if count > 0 {
+			stats = append(stats, total/float64(count))
+		}
+	}
+
+	ci := percentileInterval(FindAverageSimilarity(matrix), stats, opts.Confidence)
+	ci.Samples = n
+
+	// The standard error shrinks with the square root of the sample size
+	ci.LoopsNeeded = n
+	if margin := ci.Margin(); margin > opts.TargetMargin && opts.TargetMargin > 0 {
+		ci.LoopsNeeded = int(math.Ceil(float64(n) * (margin / opts.TargetMargin) * (margin / opts.TargetMargin)))
+	}
+	return ci
+}
+
+// BootstrapProportion resamples per-loop outcomes and recomputes the share
+// of successes
+func BootstrapProportion(outcomes []bool, opts BootstrapOptions) *ConfidenceInterval {
+	n := len(outcomes)
+	if n == 0 || opts.Iterations <= 0 {
+		return nil
+	}
+
+	successes := 0
+	for _, ok := range outcomes {
+		if ok {
+			successes++
+		}
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	stats := make([]float64, opts.Iterations)
+	for iter := range stats {
+		count := 0
+		for i := 0; i < n; i++ {
+			if outcomes[rng.Intn(n)] {
+				count++
+			}
+		}
+		stats[iter] = float64(count) / float64(n)
+	}
+
+	ci := percentileInterval(float64(successes)/float64(n), stats, opts.Confidence)
+	ci.Samples = n
+	ci.LoopsNeeded = proportionLoopsNeeded(successes, n, opts.Confidence, opts.TargetMargin)
+	return ci
+}
+
+// proportionLoopsNeeded estimates the sample size for a proportion's margin
+// of error, using the Agresti-Coull adjusted proportion so that runs where
+// every loop agrees do not claim certainty
+func proportionLoopsNeeded(successes, n int, confidence, margin float64) int {
+	if margin <= 0 {
+		return n
+	}
+	z := normalQuantile(1 - (1-confidence)/2)
+	p := (float64(successes) + z*z/2) / (float64(n) + z*z)
+	return int(math.Ceil(z * z * p * (1 - p) / (margin * margin)))
+}
+
+// wilsonInterval is the Wilson score interval of a proportion, which stays
+// wide for small samples even when every outcome is a success
+func wilsonInterval(successes, n int, confidence float64) *ConfidenceInterval {
+	p := float64(successes) / float64(n)
+	z := normalQuantile(1 - (1-confidence)/2)
+	denominator := 1 + z*z/float64(n)
+	centre := (p + z*z/(2*float64(n))) / denominator
+	halfWidth := z / denominator * math.Sqrt(p*(1-p)/float64(n)+z*z/(4*float64(n)*float64(n)))
+	return &ConfidenceInterval{
+		Estimate:   p,
+		Lower:      math.Max(0, centre-halfWidth),
+		Upper:      math.Min(1, centre+halfWidth),
+		Confidence: confidence,
+		Samples:    n,
+	}
+}
+
+// percentileInterval builds an interval from the bootstrap distribution
+func percentileInterval(estimate float64, stats []float64, confidence float64) *ConfidenceInterval {
+	ci := &ConfidenceInterval{
+		Estimate:   estimate,
+		Lower:      estimate,
+		Upper:      estimate,
+		Confidence: confidence,
+	}
+	if len(stats) == 0 {
+		return ci
+	}
+
+	sort.Float64s(stats)
+	alpha := (1 - confidence) / 2
+	ci.Lower = quantile(stats, alpha)
+	ci.Upper = quantile(stats, 1-alpha)
+	return ci
+}
+
+// quantile returns the q-th quantile of sorted values by linear interpolation
+func quantile(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	pos := q * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+	if lower == upper {
+		return sorted[lower]
+	}
+	return sorted[lower] + (pos-float64(lower))*(sorted[upper]-sorted[lower])
+}
+
+// normalQuantile returns the standard normal quantile for probability p
+func normalQuantile(p float64) float64 {
+	return math.Sqrt2 * math.Erfinv(2*p-1)
+}
+
+// dominantMembership marks which responses belong to the largest cluster
+func dominantMembership(clusters []ResponseCluster, n int) []bool {
+	members := make([]bool, n)
+	if len(clusters) == 0 {
+		return members
+	}
+	for _, i := range clusters[0].Responses {
+		if i < n {
+			members[i] = true
+		}
+	}
+	return members
+}
+
+// loopPassed returns, in loop order, whether each loop passed every assertion
+func loopPassed(summary *AssertionSummary) []bool {
+	var outcomes []bool
+	index := make(map[int]int)
+	for _, res := range summary.Results {
+		i, seen := index[res.Loop]
+		if !seen {
+			i = len(outcomes)
+			index[res.Loop] = i
+			outcomes = append(outcomes, true)
+		}
+		if !res.Passed {
+			outcomes[i] = false
+		}
+	}
+	return outcomes
+}
@@ -0,0 +1,108 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWilsonInterval(t *testing.T) {
+	// z = 1.959964 at 95%; bounds are (p + z²/2n ± z·sqrt(p(1-p)/n + z²/4n²)) / (1 + z²/n)
+	tests := []struct {
+		name         string
+		successes, n int
+		lower, upper float64
+	}{
+		{"8 of 10", 8, 10, 0.4901625, 0.9433178},
+		{"10 of 10", 10, 10, 0.7224672, 1},
+		{"0 of 10", 0, 10, 0, 0.2775328},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ci := wilsonInterval(tt.successes, tt.n, 0.95)
+			if math.Abs(ci.Lower-tt.lower) > 1e-6 || math.Abs(ci.Upper-tt.upper) > 1e-6 {
+				t.Errorf("interval = [%.7f, %.7f], want [%.7f, %.7f]", ci.Lower, ci.Upper, tt.lower, tt.upper)
+			}
+			if want := float64(tt.successes) / float64(tt.n); ci.Estimate != want {
+				t.Errorf("estimate = %v, want %v", ci.Estimate, want)
+			}
+		})
+	}
+}
+
+func TestProportionLoopsNeeded(t *testing.T) {
+	tests := []struct {
+		name         string
+		successes, n int
+		margin       float64
+		want         int
+	}{
+		// Adjusted p = (10 + z²/2) / (10 + z²) = 0.8612, z²p(1-p)/0.01 = 45.9
+		{"unanimous", 10, 10, 0.1, 46},
+		// Adjusted p = (8 + z²/2) / (10 + z²) = 0.7167, z²p(1-p)/0.0025 = 311.96
+		{"8 of 10", 8, 10, 0.05, 312},
+		{"no target", 8, 10, 0, 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := proportionLoopsNeeded(tt.successes, tt.n, 0.95, tt.margin); got != tt.want {
+				t.Errorf("loops needed = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuantile(t *testing.T) {
+	sorted := []float64{1, 2, 3, 4, 5}
+	tests := []struct {
+		q, want float64
+	}{
+		{0, 1},
+		{0.25, 2},
+		{0.1, 1.4}, // position 0.4 between 1 and 2
+		{0.975, 4.9},
+		{1, 5},
+	}
+
+	for _, tt := range tests {
+		if got := quantile(sorted, tt.q); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("quantile(%v) = %v, want %v", tt.q, got, tt.want)
+		}
+	}
+}
+
+func TestBootstrapProportion(t *testing.T) {
+	opts := DefaultBootstrapOptions()
+
+	// Every resample of identical outcomes has the same share
+	ci := BootstrapProportion([]bool{true, true, true, true}, opts)
+	if ci.Estimate != 1 || ci.Lower != 1 || ci.Upper != 1 {
+		t.Errorf("unanimous interval = %+v, want [1, 1]", *ci)
+	}
+
+	outcomes := []bool{true, true, true, true, true, true, true, true, false, false}
+	first := BootstrapProportion(outcomes, opts)
+	if first.Estimate != 0.8 || first.Lower > 0.8 || first.Upper < 0.8 || first.Lower == first.Upper {
+		t.Errorf("interval = %+v, want a non-empty interval around 0.8", *first)
+	}
+	if second := BootstrapProportion(outcomes, opts); *second != *first {
+		t.Errorf("same seed gave %+v and %+v", *first, *second)
+	}
+}
+
+func TestBootstrapAverageSimilarity(t *testing.T) {
+	// Every distinct pair is 0.5 similar, so every resample averages 0.5
+	matrix := [][]float64{
+		{1, 0.5, 0.5},
+		{0.5, 1, 0.5},
+		{0.5, 0.5, 1},
+	}
+	ci := BootstrapAverageSimilarity(matrix, DefaultBootstrapOptions())
+	if ci.Estimate != 0.5 || ci.Lower != 0.5 || ci.Upper != 0.5 {
+		t.Errorf("interval = %+v, want [0.5, 0.5]", *ci)
+	}
+	if ci.LoopsNeeded != 3 {
+		t.Errorf("loops needed = %d, want 3", ci.LoopsNeeded)
+	}
+}
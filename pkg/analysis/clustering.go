@@ -0,0 +1,450 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ClusterMethod selects the algorithm used to group responses
+type ClusterMethod string
+
+const (
+	ClusterGreedy          ClusterMethod = "greedy"
+	ClusterSingleLinkage   ClusterMethod = "single"
+	ClusterAverageLinkage  ClusterMethod = "average"
+	ClusterCompleteLinkage ClusterMethod = "complete"
+	ClusterDBSCAN          ClusterMethod = "dbscan"
+	ClusterKMedoids        ClusterMethod = "kmedoids"
+)
+
+// ClusterMethods lists the supported clustering methods
+var ClusterMethods = []ClusterMethod{
+	ClusterGreedy,
+	ClusterSingleLinkage,
+	ClusterAverageLinkage,
+	ClusterCompleteLinkage,
+	ClusterDBSCAN,
+	ClusterKMedoids,
+}
+
+// ParseClusterMethod validates a clustering method name
+func ParseClusterMethod(name string) (ClusterMethod, error) {
+	for _, method := range ClusterMethods {
+		if strings.EqualFold(name, string(method)) {
+			return method, nil
+		}
+	}
+
+	names := make([]string, len(ClusterMethods))
+	for i, method := range ClusterMethods {
+		names[i] = string(method)
+	}
+	return "", fmt.Errorf("unknown cluster method %q (expected one of: %s)", name, strings.Join(names, ", "))
+}
+
+// ClusterOptions configures how responses are clustered
+type ClusterOptions struct {
+	Method    ClusterMethod
+	Threshold float64 // minimum similarity for greedy and hierarchical merges
+	Eps       float64 // DBSCAN: minimum similarity for two responses to be neighbours
+	MinPoints int     // DBSCAN: neighbours (including itself) needed for a core response
+	K         int     // k-medoids: number of clusters, 0 selects k by silhouette
+	MaxK      int     // k-medoids: largest k tried when K is 0
+}
+
+// DefaultClusterOptions returns the options used by the analyzer
+func DefaultClusterOptions() ClusterOptions {
+	return ClusterOptions{
+		Method:    ClusterAverageLinkage,
+		Threshold: 0.7,
+		Eps:       0.7,
+		MinPoints: 2,
+		MaxK:      10,
+	}
+}
+
+// ClusterResponsesWith groups responses using the configured method
+func ClusterResponsesWith(responses []string, matrix [][]float64, opts ClusterOptions) []ResponseCluster {
+	if len(responses) == 0 {
+		return nil
+	}
+
+	switch opts.Method {
+	case ClusterGreedy:
+		return ClusterResponses(responses, matrix, opts.Threshold)
+	case ClusterSingleLinkage, ClusterCompleteLinkage, ClusterAverageLinkage:
+		return buildClusters(responses, matrix, HierarchicalClustering(matrix, opts.Method, opts.Threshold))
+	case ClusterDBSCAN:
+		return buildClusters(responses, matrix, DBSCAN(matrix, opts.Eps, opts.MinPoints))
+	case ClusterKMedoids:
+		k := opts.K
+		if k <= 0 {
+			k = SelectK(matrix, opts.MaxK)
+		}
+		return buildClusters(responses, matrix, KMedoids(matrix, k))
+	}
+
+	// Unknown methods fall back to the default linkage
+	return buildClusters(responses, matrix, HierarchicalClustering(matrix, ClusterAverageLinkage, opts.Threshold))
+}
+
+// HierarchicalClustering performs agglomerative clustering, merging the most
+// similar pair of clusters until no pair reaches the threshold
+func HierarchicalClustering(matrix [][]float64, linkage ClusterMethod, threshold float64) [][]int {
+	n := len(matrix)
+	groups := make([][]int, n)
+	for i := range groups {
+		groups[i] = []int{i}
+	}
+
+	for len(groups) > 1 {
+		bestA, bestB := -1, -1
+		bestSim := threshold
+
+		for a := 0; a < len(groups); a++ {
+			for b := a + 1; b < len(groups); b++ {
+				sim := linkageSimilarity(matrix, groups[a], groups[b], linkage)
+				if sim >= bestSim && (bestA == -1 || sim > bestSim) {
+					bestA, bestB = a, b
+					bestSim = sim
+				}
+			}
+		}
+
+		if bestA == -1 {
+			break
+		}
+
+		groups[bestA] = append(groups[bestA], groups[bestB]...)
+		groups = append(groups[:bestB], groups[bestB+1:]...)
+	}
+
+	return groups
+}
+
+// linkageSimilarity computes the similarity between two clusters
+func linkageSimilarity(matrix [][]float64, a, b []int, linkage ClusterMethod) float64 {
+	total := 0.0
+	best := -1.0
+	worst := 2.0
+
+	for _, i := range a {
+		for _, j := range b {
+			sim := matrix[i][j]
+			total += sim
+			if sim > best {
+				best = sim
+			}
+			if sim < worst {
+				worst = sim
+			}
+		}
+	}
+
+	switch linkage {
+	case ClusterSingleLinkage:
+		return best
+	case ClusterCompleteLinkage:
+		return worst
+	default:
+		return total / float64(len(a)*len(b))
+	}
+}
+
+// DBSCAN clusters responses by density over the similarity matrix. Responses
+// that are not reachable from any core response are returned as singletons.
+func DBSCAN(matrix [][]float64, eps float64, minPoints int) [][]int {
+	n := len(matrix)
+	if minPoints < 1 {
+		minPoints = 1
+	}
+
+	neighbours := make([][]int, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if i == j || matrix[i][j] >= eps {
+				neighbours[i] = append(neighbours[i], j)
+			}
+		}
+	}
+
+	const unassigned = -1
+	labels := make([]int, n)
+	for i := range labels {
+		labels[i] = unassigned
+	}
+
+	var groups [][]int
+	for i := 0; i < n; i++ {
+		if labels[i] != unassigned || len(neighbours[i]) < minPoints {
+			continue
+		}
+
+		// Expand a new cluster from this core response
+		label := len(groups)
+		groups = append(groups, nil)
+		queue := []int{i}
+		labels[i] = label
+
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			groups[label] = append(groups[label], current)
+
+			if len(neighbours[current]) < minPoints {
+				continue // border response, do not expand further
+			}
+			for _, j := range neighbours[current] {
+				if labels[j] == unassigned {
+					labels[j] = label
+					queue = append(queue, j)
+				}
+			}
+		}
+	}
+
+	// Noise responses become their own clusters
+	for i := 0; i < n; i++ {
+		if labels[i] == unassigned {
+			groups = append(groups, []int{i})
+		}
+	}
+
+	return groups
+}
+
+// KMedoids partitions responses into k clusters around actual responses using
+// the PAM build and swap phases on distance (1 - similarity)
+func KMedoids(matrix [][]float64, k int) [][]int {
+	n := len(matrix)
+	if n == 0 {
+		return nil
+	}
+	if k < 1 {
+		k = 1
+	}
+	if k > n {
+		k = n
+	}
+
+	isMedoid := make([]bool, n)
+	var medoids []int
+
+	// Build: greedily add the medoid that most reduces total distance
+	for len(medoids) < k {
+		bestIndex := -1
+		bestCost := 0.0
+		for candidate := 0; candidate < n; candidate++ {
+			if isMedoid[candidate] {
+				continue
+			}
+			cost := assignmentCost(matrix, append(medoids, candidate))
+			if bestIndex == -1 || cost < bestCost {
+				bestIndex = candidate
+				bestCost = cost
+			}
+		}
+		medoids = append(medoids, bestIndex)
+		isMedoid[bestIndex] = true
+	}
+
+	// Swap: replace medoids while the total distance keeps improving
+	currentCost := assignmentCost(matrix, medoids)
+	for improved := true; improved; {
+		improved = false
+		for m := range medoids {
+			for candidate := 0; candidate < n; candidate++ {
+				if isMedoid[candidate] {
+					continue
+				}
+				trial := append([]int(nil), medoids...)
+				trial[m] = candidate
+				if cost := assignmentCost(matrix, trial); cost < currentCost-1e-12 {
+					isMedoid[medoids[m]] = false
+					isMedoid[candidate] = true
+					medoids = trial
+					currentCost = cost
+					improved = true
+				}
+			}
+		}
+	}
+
+	groups := make([][]int, len(medoids))
+	for i := 0; i < n; i++ {
+		m := nearestMedoid(matrix, medoids, i)
+		groups[m] = append(groups[m], i)
+	}
+
+	return groups
+}
+
+// assignmentCost sums each response's distance to its nearest medoid
+func assignmentCost(matrix [][]float64, medoids []int) float64 {
+	cost := 0.0
+	for i := range matrix {
+		cost += 1.0 - matrix[i][medoids[nearestMedoid(matrix, medoids, i)]]
+	}
+	return cost
+}
+
+// nearestMedoid returns the position in medoids of the medoid most similar to i
+func nearestMedoid(matrix [][]float64, medoids []int, i int) int {
+	best := 0
+	for m := 1; m < len(medoids); m++ {
+		if matrix[i][medoids[m]] > matrix[i][medoids[best]] {
+			best = m
+		}
+	}
+	return best
+}
+
+// SelectK picks the k-medoids cluster count with the highest average
+// silhouette. A single cluster is chosen when no split scores above zero.
+func SelectK(matrix [][]float64, maxK int) int {
+	n := len(matrix)
+	if maxK <= 0 || maxK > n-1 {
+		maxK = n - 1
+	}
+
+	bestK := 1
+	bestScore := 0.0
+	for k := 2; k <= maxK; k++ {
+		_, score := Silhouette(matrix, KMedoids(matrix, k))
+		if score > bestScore {
+			bestK = k
+			bestScore = score
+		}
+	}
+
+	return bestK
+}
+
+// Silhouette computes per-response silhouette scores and their average for a
+// clustering. Responses in singleton clusters score 0.
+func Silhouette(matrix [][]float64, groups [][]int) ([]float64, float64) {
+	n := len(matrix)
+	scores := make([]float64, n)
+	if n == 0 || len(groups) < 2 {
+		return scores, 0.0
+	}
+
+	label := make([]int, n)
+	for g, members := range groups {
+		for _, i := range members {
+			label[i] = g
+		}
+	}
+
+	total := 0.0
+	for i := 0; i < n; i++ {
+		own := groups[label[i]]
+		if len(own) < 2 {
+			continue
+		}
+
+		a := meanDistance(matrix, i, own)
+		b := -1.0
+		for g, members := range groups {
+			if g == label[i] {
+				continue
+			}
+			if d := meanDistance(matrix, i, members); b < 0 || d < b {
+				b = d
+			}
+		}
+
+		denominator := a
+		if b > denominator {
+			denominator = b
+		}
+		if denominator > 0 {
+			scores[i] = (b - a) / denominator
+		}
+		total += scores[i]
+	}
+
+	return scores, total / float64(n)
+}
+
+// meanDistance averages the distance from i to the other members of a group
+func meanDistance(matrix [][]float64, i int, members []int) float64 {
+	total := 0.0
+	count := 0
+	for _, j := range members {
+		if j == i {
+			continue
+		}
+		total += 1.0 - matrix[i][j]
+		count++
+	}
+	if count == 0 {
+		return 0.0
+	}
+	return total / float64(count)
+}
+
+// buildClusters turns index groups into clusters represented by their medoid,
+// sorted by size (largest first) and then by earliest member
+func buildClusters(responses []string, matrix [][]float64, groups [][]int) []ResponseCluster {
+	scores, _ := Silhouette(matrix, groups)
+
+	clusters := make([]ResponseCluster, 0, len(groups))
+	for _, members := range groups {
+		if len(members) == 0 {
+			continue
+		}
+		sorted := append([]int(nil), members...)
+		sort.Ints(sorted)
+
+		medoid := findMedoid(matrix, sorted)
+		silhouette := 0.0
+		for _, i := range sorted {
+			silhouette += scores[i]
+		}
+
+		clusters = append(clusters, ResponseCluster{
+			Responses:  sorted,
+			Centroid:   responses[medoid],
+			Size:       len(sorted),
+			Medoid:     medoid,
+			Silhouette: silhouette / float64(len(sorted)),
+		})
+	}
+
+	sort.SliceStable(clusters, func(i, j int) bool {
+		if clusters[i].Size != clusters[j].Size {
+			return clusters[i].Size > clusters[j].Size
+		}
+		return clusters[i].Responses[0] < clusters[j].Responses[0]
+	})
+
+	return clusters
+}
+
+// findMedoid returns the member with the highest total similarity to the rest
+func findMedoid(matrix [][]float64, members []int) int {
+	best := members[0]
+	bestTotal := -1.0
+	for _, i := range members {
+		total := 0.0
+		for _, j := range members {
+			total += matrix[i][j]
+		}
+		if total > bestTotal {
+			best = i
+			bestTotal = total
+		}
+	}
+	return best
+}
+
+// clusterGroups extracts the member indices of each cluster
+func clusterGroups(clusters []ResponseCluster) [][]int {
+	groups := make([][]int, len(clusters))
+	for i, cluster := range clusters {
+		groups[i] = cluster.Responses
+	}
+	return groups
+}
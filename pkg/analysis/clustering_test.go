@@ -0,0 +1,158 @@
+package analysis
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// lineMatrix places points on a line and scores the similarity of two as
+// 1 - distance/scale, so expected results can be worked out by hand
+func lineMatrix(xs []float64, scale float64) [][]float64 {
+	matrix := make([][]float64, len(xs))
+	for i := range xs {
+		matrix[i] = make([]float64, len(xs))
+		for j := range xs {
+			matrix[i][j] = 1 - math.Abs(xs[i]-xs[j])/scale
+		}
+	}
+	return matrix
+}
+
+// normalizeGroups sorts the members of each group and the groups by their
+// first member, so groupings compare regardless of order
+func normalizeGroups(groups [][]int) [][]int {
+	normalized := make([][]int, 0, len(groups))
+	for _, group := range groups {
+		sorted := append([]int(nil), group...)
+		sort.Ints(sorted)
+		normalized = append(normalized, sorted)
+	}
+	sort.Slice(normalized, func(i, j int) bool {
+		return normalized[i][0] < normalized[j][0]
+	})
+	return normalized
+}
+
+func TestHierarchicalClustering(t *testing.T) {
+	// 0-1 and 1-2 are 0.95 similar, 0-2 only 0.9; 10-11 are 0.95
+	matrix := lineMatrix([]float64{0, 1, 2, 10, 11}, 20)
+
+	tests := []struct {
+		linkage   ClusterMethod
+		threshold float64
+		want      [][]int
+	}{
+		// Chains through 1 although 0 and 2 are below the threshold
+		{ClusterSingleLinkage, 0.92, [][]int{{0, 1, 2}, {3, 4}}},
+		// {0,1} to 2: mean of 0.9 and 0.95 is 0.925
+		{ClusterAverageLinkage, 0.92, [][]int{{0, 1, 2}, {3, 4}}},
+		// {0,1} to 2: the worst pair is 0.9
+		{ClusterCompleteLinkage, 0.92, [][]int{{0, 1}, {2}, {3, 4}}},
+		// Across the gap the best pair, 2-10, is 0.6
+		{ClusterSingleLinkage, 0.6, [][]int{{0, 1, 2, 3, 4}}},
+		{ClusterSingleLinkage, 0.61, [][]int{{0, 1, 2}, {3, 4}}},
+	}
+
+	for _, tt := range tests {
+		got := normalizeGroups(HierarchicalClustering(matrix, tt.linkage, tt.threshold))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s linkage at %.2f = %v, want %v", tt.linkage, tt.threshold, got, tt.want)
+		}
+	}
+}
+
+func TestDBSCAN(t *testing.T) {
+	// With eps 0.92 neighbours are at most 1.6 apart: 1 has three
+	// (itself, 0 and 2), 0, 2, 10 and 11 have two and 20 only itself
+	matrix := lineMatrix([]float64{0, 1, 2, 10, 11, 20}, 20)
+
+	tests := []struct {
+		minPoints int
+		want      [][]int
+	}{
+		// Only 1 is a core point: 0 and 2 join as border points, the rest is noise
+		{3, [][]int{{0, 1, 2}, {3}, {4}, {5}}},
+		{2, [][]int{{0, 1, 2}, {3, 4}, {5}}},
+		{1, [][]int{{0, 1, 2}, {3, 4}, {5}}},
+	}
+
+	for _, tt := range tests {
+		got := normalizeGroups(DBSCAN(matrix, 0.92, tt.minPoints))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("minPoints %d = %v, want %v", tt.minPoints, got, tt.want)
+		}
+	}
+}
+
+func TestKMedoids(t *testing.T) {
+	matrix := lineMatrix([]float64{0, 1, 2, 10, 11, 12}, 20)
+
+	tests := []struct {
+		k    int
+		want [][]int
+	}{
+		{1, [][]int{{0, 1, 2, 3, 4, 5}}},
+		{2, [][]int{{0, 1, 2}, {3, 4, 5}}},
+		{6, [][]int{{0}, {1}, {2}, {3}, {4}, {5}}},
+	}
+
+	for _, tt := range tests {
+		got := normalizeGroups(KMedoids(matrix, tt.k))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("k=%d = %v, want %v", tt.k, got, tt.want)
+		}
+	}
+
+	if k := SelectK(matrix, 0); k != 2 {
+		t.Errorf("SelectK() = %d, want 2", k)
+	}
+}
+
+func TestSilhouette(t *testing.T) {
+	// Distances are |x_i - x_j| / 10: 0.2 within {0, 2}, 1.0 and 0.8 to 10
+	matrix := lineMatrix([]float64{0, 2, 10}, 10)
+
+	tests := []struct {
+		name    string
+		groups  [][]int
+		scores  []float64
+		average float64
+	}{
+		{
+			// s(0) = (1.0-0.2)/1.0, s(1) = (0.8-0.2)/0.8, singletons score 0
+			name:    "pair and singleton",
+			groups:  [][]int{{0, 1}, {2}},
+			scores:  []float64{0.8, 0.75, 0},
+			average: (0.8 + 0.75) / 3,
+		},
+		{
+			// s(1) = (0.2-0.8)/0.8 and s(2) = (1.0-0.8)/1.0
+			name:    "misplaced point",
+			groups:  [][]int{{0}, {1, 2}},
+			scores:  []float64{0, -0.75, 0.2},
+			average: (-0.75 + 0.2) / 3,
+		},
+		{
+			name:    "one cluster",
+			groups:  [][]int{{0, 1, 2}},
+			scores:  []float64{0, 0, 0},
+			average: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scores, average := Silhouette(matrix, tt.groups)
+			for i := range tt.scores {
+				if math.Abs(scores[i]-tt.scores[i]) > 1e-9 {
+					t.Errorf("score %d = %.6f, want %.6f", i, scores[i], tt.scores[i])
+				}
+			}
+			if math.Abs(average-tt.average) > 1e-9 {
+				t.Errorf("average = %.6f, want %.6f", average, tt.average)
+			}
+		})
+	}
+}
@@ -0,0 +1,374 @@
+package analysis
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	fenceOpenRegex  = regexp.MustCompile("^\\s*```+\\s*([A-Za-z0-9_+#.-]*)")
+	fenceCloseRegex = regexp.MustCompile("^\\s*```+\\s*$")
+)
+
+// languageAliases maps fence tags to a canonical language name
+var languageAliases = map[string]string{
+	"golang": "go",
+	"sh":     "bash",
+	"shell":  "bash",
+	"js":     "javascript",
+	"ts":     "typescript",
+	"py":     "python",
+	"yml":    "yaml",
+}
+
+// CodeBlock is a fenced code block in a response
+type CodeBlock struct {
+	Language string // lowercase fence tag, empty when untagged
+	Code     string
+	Line     int // line of the opening fence, from 1
+}
+
+// CodeOptions configures code block checks
+type CodeOptions struct {
+	Vet        bool // run go vet on Go blocks in a temporary module
+	VetTimeout time.Duration
+}
+
+// DefaultCodeOptions returns options that parse and format-check Go blocks
+// without running go vet
+func DefaultCodeOptions() CodeOptions {
+	return CodeOptions{VetTimeout: 2 * time.Minute}
+}
+
+// GoCheck is the result of checking one Go code block
+type GoCheck struct {
+	Block      int    // index of the block in the loop's blocks
+	Fragment   string // file, declarations or statements
+	Parses     bool
+	ParseError string
+	Formatted  bool // unchanged by gofmt
+	Vetted     bool // go vet ran on the block
+	VetClean   bool // go vet built the block and reported nothing
+	VetOutput  string
+}
+
+// LoopCode is the code found in one loop's response
+type LoopCode struct {
+	Loop   int
+	Blocks []CodeBlock
+	Go     []GoCheck
+}
+
+// LanguageCount is how often a language appeared across loops
+type LanguageCount struct {
+	Language string
+	Blocks   int
+	Loops    int
+}
+
+// CodeSummary compares the code in an agent's responses separately from the
+// surrounding prose
+type CodeSummary struct {
+	Responses       int
+	LoopsWithCode   int
+	Languages       []LanguageCount
+	GoBlocks        int
+	GoParsed        int
+	GoFormatted     int
+	GoVetted        int
+	GoVetClean      int
+	CodeSimilarity  float64 // average similarity of the code of loops with code
+	ProseSimilarity float64 // average similarity of the responses without code
+	Loops           []LoopCode
+}
+
+// ExtractCodeBlocks returns the fenced code blocks in a text. An unterminated
+// block runs to the end of the text, as truncated responses often end inside
+// one.
+func ExtractCodeBlocks(text string) []CodeBlock {
+	var blocks []CodeBlock
+	var current *CodeBlock
+	var code []string
+
+	for i, line := range strings.Split(text, "\n") {
+		if current == nil {
+			if match := fenceOpenRegex.FindStringSubmatch(line); match != nil {
+				current = &CodeBlock{Language: normalizeLanguage(match[1]), Line: i + 1}
+				code = code[:0]
+			}
+			continue
+		}
+		if fenceCloseRegex.MatchString(line) {
+			current.Code = strings.Join(code, "\n")
+			blocks = append(blocks, *current)
+			current = nil
+			continue
+		}
+		code = append(code, line)
+	}
+	if current != nil {
+		current.Code = strings.Join(code, "\n")
+		blocks = append(blocks, *current)
+	}
+	return blocks
+}
+
+// StripCodeBlocks removes fenced code blocks from a text, leaving the prose
+func StripCodeBlocks(text string) string {
+	var prose []string
+	inBlock := false
+	for _, line := range strings.Split(text, "\n") {
+		switch {
+		case !inBlock && fenceOpenRegex.MatchString(line):
+			inBlock = true
+		case inBlock && fenceCloseRegex.MatchString(line):
+			inBlock = false
+		case !inBlock:
+			prose = append(prose, line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(prose, "\n"))
+}
+
+// normalizeLanguage lowercases a fence tag and resolves common aliases
+func normalizeLanguage(tag string) string {
+	tag = strings.ToLower(tag)
+	if alias, ok := languageAliases[tag]; ok {
+		return alias
+	}
+	return tag
+}
+
+// isGoBlock reports whether a block should be checked as Go: tagged go, or
+// untagged and starting with a package clause
+func isGoBlock(block CodeBlock) bool {
+	if block.Language == "go" {
+		return true
+	}
+	return block.Language == "" && strings.HasPrefix(strings.TrimSpace(block.Code), "package ")
+}
+
+// CheckGoCode parses and format-checks a Go block, and runs go vet on it
+// when opts.Vet is set. Blocks without a package clause are checked as
+// top-level declarations, then as statements inside a function.
+func CheckGoCode(code string, opts CodeOptions) GoCheck {
+	check := GoCheck{}
+
+	source, fragment, err := parseGoFragment(code)
+	check.Fragment = fragment
+	if err != nil {
+		check.ParseError = err.Error()
+		return check
+	}
+	check.Parses = true
+
+	// format.Source accepts declaration and statement lists as well as files
+	formatted, err := format.Source([]byte(code))
+	check.Formatted = err == nil && strings.TrimSpace(string(formatted)) == strings.TrimSpace(code)
+
+	// Bare statements would need invented declarations to type-check
+	if opts.Vet && fragment != "statements" {
+		check.Vetted = true
+		check.VetClean, check.VetOutput = vetGoSource(source, opts.VetTimeout)
+	}
+	return check
+}
+
+// parseGoFragment parses a block as a file, a declaration list or a statement
+// list, returning a complete source file for it
+func parseGoFragment(code string) (string, string, error) {
+	fset := token.NewFileSet()
+	_, fileErr := parser.ParseFile(fset, "main.go", code, parser.AllErrors)
+	if fileErr == nil {
+		return code, "file", nil
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(code), "package ") {
+		return "", "file", fileErr
+	}
+	// Prefixes stay on the first line so positions match the block
+	source := "package main; " + code
+	_, declErr := parser.ParseFile(fset, "main.go", source, parser.AllErrors)
+	if declErr == nil {
+		return source, "declarations", nil
+	}
+	statements := "package main; func _() { " + code + "\n}\n"
+	if _, err := parser.ParseFile(fset, "main.go", statements, parser.AllErrors); err == nil {
+		return statements, "statements", nil
+	}
+	return "", "declarations", declErr
+}
+
+// vetGoSource runs go vet on a source file in a temporary module, returning
+// whether it passed and its output with the temporary paths removed
+func vetGoSource(source string, timeout time.Duration) (bool, string) {
+	dir, err := os.MkdirTemp("", "analyze-vet-")
+	if err != nil {
+		return false, err.Error()
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module vetcheck\n\ngo 1.21\n"), 0644); err != nil {
+		return false, err.Error()
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(source), 0644); err != nil {
+		return false, err.Error()
+	}
+
+	if timeout <= 0 {
+		timeout = 2 * time.Minute
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "go", "vet", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOWORK=off", "GOTOOLCHAIN=local")
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err = cmd.Run()
+
+	text := strings.ReplaceAll(output.String(), dir+string(filepath.Separator), "")
+	text = strings.TrimSpace(strings.ReplaceAll(text, dir, "."))
+	if err != nil && text == "" {
+		text = err.Error()
+	}
+	return err == nil, text
+}
+
+// AnalyzeCode extracts the code blocks from an agent's responses, checks the
+// Go blocks and compares code and prose separately. It returns nil when no
+// response contains a code block.
+func AnalyzeCode(entries []LogEntry, opts CodeOptions) *CodeSummary {
+	summary := &CodeSummary{Responses: len(entries)}
+	languages := make(map[string]*LanguageCount)
+	checked := make(map[string]GoCheck) // identical blocks are only checked once
+
+	var code, prose []string
+	for _, entry := range entries {
+		response := getResponseFromEntry(entry)
+		prose = append(prose, StripCodeBlocks(response))
+
+		blocks := ExtractCodeBlocks(response)
+		if len(blocks) == 0 {
+			continue
+		}
+		summary.LoopsWithCode++
+		loop := LoopCode{Loop: entry.Loop, Blocks: blocks}
+
+		seen := make(map[string]bool)
+		var loopCode []string
+		for i, block := range blocks {
+			loopCode = append(loopCode, block.Code)
+
+			name := block.Language
+			if name == "" {
+				name = "untagged"
+			}
+			count, ok := languages[name]
+			if !ok {
+				count = &LanguageCount{Language: name}
+				languages[name] = count
+			}
+			count.Blocks++
+			if !seen[name] {
+				seen[name] = true
+				count.Loops++
+			}
+
+			if !isGoBlock(block) {
+				continue
+			}
+			check, ok := checked[block.Code]
+			if !ok {
+				check = CheckGoCode(block.Code, opts)
+				checked[block.Code] = check
+			}
+			check.Block = i
+			loop.Go = append(loop.Go, check)
+			summary.countGoCheck(check)
+		}
+		code = append(code, strings.Join(loopCode, "\n"))
+		summary.Loops = append(summary.Loops, loop)
+	}
+	if summary.LoopsWithCode == 0 {
+		return nil
+	}
+
+	for _, count := range languages {
+		summary.Languages = append(summary.Languages, *count)
+	}
+	sort.Slice(summary.Languages, func(i, j int) bool {
+		if summary.Languages[i].Blocks != summary.Languages[j].Blocks {
+			return summary.Languages[i].Blocks > summary.Languages[j].Blocks
+		}
+		return summary.Languages[i].Language < summary.Languages[j].Language
+	})
+
+	// Always lexical: go-ast would score the prose 0 and a judge would double
+	// the calls of --metric judge
+	summary.CodeSimilarity = FindAverageSimilarity(CalculateSimilarityMatrix(code))
+	summary.ProseSimilarity = FindAverageSimilarity(CalculateSimilarityMatrix(prose))
+	return summary
+}
+
+// countGoCheck adds a Go block's check to the totals
+func (s *CodeSummary) countGoCheck(check GoCheck) {
+	s.GoBlocks++
+	if check.Parses {
+		s.GoParsed++
+	}
+	if check.Formatted {
+		s.GoFormatted++
+	}
+	if check.Vetted {
+		s.GoVetted++
+		if check.VetClean {
+			s.GoVetClean++
+		}
+	}
+}
+
+// Status summarises a Go check in a few words
+func (c GoCheck) Status() string {
+	if !c.Parses {
+		return "parse error: " + c.ParseError
+	}
+	status := []string{"parses (" + c.Fragment + ")"}
+	if c.Formatted {
+		status = append(status, "gofmt clean")
+	} else {
+		status = append(status, "needs gofmt")
+	}
+	if c.Vetted {
+		if c.VetClean {
+			status = append(status, "vet clean")
+		} else {
+			status = append(status, fmt.Sprintf("vet failed: %s", firstLine(c.VetOutput)))
+		}
+	}
+	return strings.Join(status, ", ")
+}
+
+// firstLine returns the first non-comment line of a command's output
+func firstLine(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return line
+		}
+	}
+	return strings.TrimSpace(output)
+}
@@ -0,0 +1,299 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CompareOptions configures regression detection between two runs. A metric
+// regresses when it moves in the bad direction by more than its threshold
+// and, if RequireSignificance is set, the change is statistically significant.
+type CompareOptions struct {
+	Alpha                float64 // significance level for the tests
+	RequireSignificance  bool
+	MaxSimilarityDrop    float64 // absolute drop in average similarity
+	MaxMajorityShareDrop float64 // absolute drop in the largest cluster's share
+	MaxPassRateDrop      float64 // absolute drop in assertion pass rate
+	MaxErrorRateIncrease float64 // absolute increase in the share of loops with errors
+	MaxLatencyIncrease   float64 // relative increase in median latency, e.g. 0.25 for +25%
+}
+
+// DefaultCompareOptions returns the regression thresholds used by compare
+func DefaultCompareOptions() CompareOptions {
+	return CompareOptions{
+		Alpha:                0.05,
+		RequireSignificance:  true,
+		MaxSimilarityDrop:    0.05,
+		MaxMajorityShareDrop: 0.1,
+		MaxPassRateDrop:      0.05,
+		MaxErrorRateIncrease: 0.05,
+		MaxLatencyIncrease:   0.25,
+	}
+}
+
+// MetricDelta is the change of one metric between the base and candidate runs
+type MetricDelta struct {
+	Metric      string
+	Base        float64
+	Candidate   float64
+	Delta       float64 // candidate - base (relative for latency)
+	Test        string  // statistical test used, empty when none applies
+	PValue      float64
+	Significant bool
+	Regression  bool
+}
+
+// AgentComparison compares one agent's analyses across two runs
+type AgentComparison struct {
+	Agent                     string
+	Deltas                    []MetricDelta
+	BaseClusters              int
+	CandidateClusters         int
+	BasePattern               string
+	CandidatePattern          string
+	DominantPatternSimilarity float64 // similarity between the two dominant patterns
+	CrossRunSimilarity        float64 // mean similarity of base responses to candidate responses
+}
+
+// RunComparison is the result of comparing two runs
+type RunComparison struct {
+	Deltas      []MetricDelta // run-level metrics (errors, latency)
+	Main        *AgentComparison
+	Sub         *AgentComparison
+	Regressions []string
+}
+
+// Regressed reports whether any metric regressed beyond its threshold
+func (c *RunComparison) Regressed() bool {
+	return len(c.Regressions) > 0
+}
+
+// CompareRuns compares a candidate run against a base run
+func CompareRuns(base, candidate *DualAgentAnalysisResult, opts CompareOptions) *RunComparison {
+	comparison := &RunComparison{}
+
+	// Run-level error rate
+	baseErrors, baseEntries := countErrors(base.Entries)
+	candidateErrors, candidateEntries := countErrors(candidate.Entries)
+	errorDelta := proportionDelta("error_rate", baseErrors, baseEntries, candidateErrors, candidateEntries, opts.Alpha)
+	errorDelta.Regression = errorDelta.Delta > opts.MaxErrorRateIncrease && (errorDelta.Significant || !opts.RequireSignificance)
+	comparison.Deltas = append(comparison.Deltas, errorDelta)
+
+	// Run-level latency, compared by median with a rank test
+	baseLatency := executionSeconds(base.Entries)
+	candidateLatency := executionSeconds(candidate.Entries)
+	if len(baseLatency) > 0 && len(candidateLatency) > 0 {
+		latencyDelta := MetricDelta{
+			Metric:    "median_latency_seconds",
+			Base:      median(baseLatency),
+			Candidate: median(candidateLatency),
+			Test:      "mann-whitney",
+		}
+		if latencyDelta.Base > 0 {
+			latencyDelta.Delta = (latencyDelta.Candidate - latencyDelta.Base) / latencyDelta.Base
+		}
+		_, latencyDelta.PValue = MannWhitneyU(baseLatency, candidateLatency)
+		latencyDelta.Significant = latencyDelta.PValue < opts.Alpha
+		latencyDelta.Regression = latencyDelta.Delta > opts.MaxLatencyIncrease && (latencyDelta.Significant || !opts.RequireSignificance)
+		comparison.Deltas = append(comparison.Deltas, latencyDelta)
+	}
+
+	comparison.Main = compareAgents("main", base.MainAgentAnalysis, candidate.MainAgentAnalysis, opts)
+	comparison.Sub = compareAgents("sub", base.SubAgentAnalysis, candidate.SubAgentAnalysis, opts)
+
+	for _, delta := range comparison.Deltas {
+		if delta.Regression {
+			comparison.Regressions = append(comparison.Regressions, describeRegression("", delta))
+		}
+	}
+	for _, agent := range []*AgentComparison{comparison.Main, comparison.Sub} {
+		if agent == nil {
+			continue
+		}
+		for _, delta := range agent.Deltas {
+			if delta.Regression {
+				comparison.Regressions = append(comparison.Regressions, describeRegression(agent.Agent, delta))
+			}
+		}
+	}
+
+	return comparison
+}
+
+// compareAgents compares one agent's metrics, returning nil if either run
+// has no responses for the agent
+func compareAgents(agent string, base, candidate *AnalysisResult, opts CompareOptions) *AgentComparison {
+	if base == nil || candidate == nil {
+		return nil
+	}
+
+	comparison := &AgentComparison{
+		Agent:                     agent,
+		BaseClusters:              len(base.Clusters),
+		CandidateClusters:         len(candidate.Clusters),
+		BasePattern:               base.MostCommonPattern,
+		CandidatePattern:          candidate.MostCommonPattern,
+		DominantPatternSimilarity: OverallSimilarity(base.MostCommonPattern, candidate.MostCommonPattern),
+		CrossRunSimilarity:        crossRunSimilarity(responseTexts(base), responseTexts(candidate)),
+	}
+
+	// Similarity, tested on each response's mean similarity to its run
+	similarity := MetricDelta{
+		Metric:    "average_similarity",
+		Base:      base.AverageSimilarity,
+		Candidate: candidate.AverageSimilarity,
+		Delta:     candidate.AverageSimilarity - base.AverageSimilarity,
+		Test:      "mann-whitney",
+	}
+	_, similarity.PValue = MannWhitneyU(meanSimilarities(base.SimilarityMatrix), meanSimilarities(candidate.SimilarityMatrix))
+	similarity.Significant = similarity.PValue < opts.Alpha
+	similarity.Regression = -similarity.Delta > opts.MaxSimilarityDrop && (similarity.Significant || !opts.RequireSignificance)
+	comparison.Deltas = append(comparison.Deltas, similarity)
+
+	// Share of the dominant cluster
+	majority := proportionDelta("majority_share",
+		base.MostCommonCount, base.TotalResponses,
+		candidate.MostCommonCount, candidate.TotalResponses, opts.Alpha)
+	majority.Regression = -majority.Delta > opts.MaxMajorityShareDrop && (majority.Significant || !opts.RequireSignificance)
+	comparison.Deltas = append(comparison.Deltas, majority)
+
+	comparison.Deltas = append(comparison.Deltas, MetricDelta{
+		Metric:    "cluster_count",
+		Base:      float64(len(base.Clusters)),
+		Candidate: float64(len(candidate.Clusters)),
+		Delta:     float64(len(candidate.Clusters) - len(base.Clusters)),
+	})
+
+	if base.Assertions != nil && candidate.Assertions != nil {
+		passRate := proportionDelta("pass_rate",
+			base.Assertions.LoopsPassed, base.Assertions.LoopsEvaluated,
+			candidate.Assertions.LoopsPassed, candidate.Assertions.LoopsEvaluated, opts.Alpha)
+		passRate.Regression = -passRate.Delta > opts.MaxPassRateDrop && (passRate.Significant || !opts.RequireSignificance)
+		comparison.Deltas = append(comparison.Deltas, passRate)
+	}
+
+	if base.Score != nil && candidate.Score != nil {
+		comparison.Deltas = append(comparison.Deltas, MetricDelta{
+			Metric:    "composite_score",
+			Base:      base.Score.Composite,
+			Candidate: candidate.Score.Composite,
+			Delta:     candidate.Score.Composite - base.Score.Composite,
+		})
+	}
+
+	return comparison
+}
+
+// proportionDelta compares two proportions with a two-proportion z-test
+func proportionDelta(metric string, baseSuccesses, baseTotal, candidateSuccesses, candidateTotal int, alpha float64) MetricDelta {
+	delta := MetricDelta{Metric: metric, Test: "two-proportion z"}
+	if baseTotal > 0 {
+		delta.Base = float64(baseSuccesses) / float64(baseTotal)
+	}
+	if candidateTotal > 0 {
+		delta.Candidate = float64(candidateSuccesses) / float64(candidateTotal)
+	}
+	delta.Delta = delta.Candidate - delta.Base
+	delta.PValue = TwoProportionZTest(baseSuccesses, baseTotal, candidateSuccesses, candidateTotal)
+	delta.Significant = delta.PValue < alpha
+	return delta
+}
+
+// crossRunSimilarity averages the similarity of every base response to every
+// candidate response
+func crossRunSimilarity(base, candidate []string) float64 {
+	if len(base) == 0 || len(candidate) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, a := range base {
+		for _, b := range candidate {
+			total += OverallSimilarity(a, b)
+		}
+	}
+	return total / float64(len(base)*len(candidate))
+}
+
+// countErrors counts entries with stderr output
+func countErrors(entries []LogEntry) (int, int) {
+	errors := 0
+	for _, entry := range entries {
+		if strings.TrimSpace(entry.Errors) != "" {
+			errors++
+		}
+	}
+	return errors, len(entries)
+}
+
+// executionSeconds collects recorded execution times in seconds
+func executionSeconds(entries []LogEntry) []float64 {
+	var seconds []float64
+	for _, entry := range entries {
+		if entry.ExecutionTime > 0 {
+			seconds = append(seconds, entry.ExecutionTime.Seconds())
+		}
+	}
+	return seconds
+}
+
+// describeRegression formats a regression for the summary
+func describeRegression(agent string, delta MetricDelta) string {
+	name := delta.Metric
+	if agent != "" {
+		name = agent + " " + name
+	}
+	if delta.Test == "" {
+		return fmt.Sprintf("%s: %.3f -> %.3f", name, delta.Base, delta.Candidate)
+	}
+	return fmt.Sprintf("%s: %.3f -> %.3f (p=%.4f)", name, delta.Base, delta.Candidate, delta.PValue)
+}
+
+// PrintRunComparison formats and displays a run comparison
+func PrintRunComparison(comparison *RunComparison, baseName, candidateName string) {
+	fmt.Println("=== RELIABILITY RUN COMPARISON ===")
+	fmt.Printf("Base:      %s\n", baseName)
+	fmt.Printf("Candidate: %s\n", candidateName)
+
+	fmt.Println("\n--- RUN METRICS ---")
+	printDeltas(comparison.Deltas)
+
+	for _, agent := range []*AgentComparison{comparison.Main, comparison.Sub} {
+		if agent == nil {
+			continue
+		}
+		fmt.Printf("\n--- %s AGENT ---\n", strings.ToUpper(agent.Agent))
+		printDeltas(agent.Deltas)
+		fmt.Printf("Clusters: %d -> %d\n", agent.BaseClusters, agent.CandidateClusters)
+		fmt.Printf("Dominant pattern similarity: %.3f\n", agent.DominantPatternSimilarity)
+		fmt.Printf("  Base:      \"%s\"\n", truncateString(agent.BasePattern, 80))
+		fmt.Printf("  Candidate: \"%s\"\n", truncateString(agent.CandidatePattern, 80))
+		fmt.Printf("Cross-run similarity: %.3f\n", agent.CrossRunSimilarity)
+	}
+
+	fmt.Println("\n--- VERDICT ---")
+	if !comparison.Regressed() {
+		fmt.Println("No regressions detected")
+		return
+	}
+	fmt.Printf("%d regression(s) detected:\n", len(comparison.Regressions))
+	for _, regression := range comparison.Regressions {
+		fmt.Printf("  REGRESSION %s\n", regression)
+	}
+}
+
+// printDeltas prints a table of metric deltas
+func printDeltas(deltas []MetricDelta) {
+	for _, d := range deltas {
+		line := fmt.Sprintf("%-24s %8.3f -> %8.3f  (%+.3f)", d.Metric, d.Base, d.Candidate, d.Delta)
+		if d.Test != "" {
+			line += fmt.Sprintf("  p=%.4f %s", d.PValue, d.Test)
+			if d.Significant {
+				line += " *"
+			}
+		}
+		if d.Regression {
+			line += "  REGRESSION"
+		}
+		fmt.Println(line)
+	}
+}
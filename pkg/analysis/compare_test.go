@@ -0,0 +1,37 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCompareRunsErrorRate(t *testing.T) {
+	entries := func(errors int) []LogEntry {
+		result := make([]LogEntry, 10)
+		for i := range result {
+			result[i].Loop = i + 1
+			if i < errors {
+				result[i].Errors = "failed"
+			}
+		}
+		return result
+	}
+	base := &DualAgentAnalysisResult{Entries: entries(2)}
+	candidate := &DualAgentAnalysisResult{Entries: entries(6)}
+
+	// 0.2 to 0.6 exceeds the threshold, but p = 0.0679 is not significant
+	comparison := CompareRuns(base, candidate, DefaultCompareOptions())
+	delta := comparison.Deltas[0]
+	if delta.Metric != "error_rate" || math.Abs(delta.Delta-0.4) > 1e-9 || math.Abs(delta.PValue-0.0678892) > 1e-6 {
+		t.Errorf("error rate delta = %+v, want +0.4 with p 0.0678892", delta)
+	}
+	if comparison.Regressed() {
+		t.Errorf("regressions = %v, want none without significance", comparison.Regressions)
+	}
+
+	opts := DefaultCompareOptions()
+	opts.RequireSignificance = false
+	if comparison := CompareRuns(base, candidate, opts); !comparison.Regressed() {
+		t.Error("error rate increase not reported as a regression")
+	}
+}
@@ -0,0 +1,285 @@
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ConstraintType identifies the format rule a constraint enforces
+type ConstraintType string
+
+const (
+	ConstraintMaxWords   ConstraintType = "max_words"
+	ConstraintMaxLines   ConstraintType = "max_lines"
+	ConstraintMaxChars   ConstraintType = "max_chars"
+	ConstraintJSON       ConstraintType = "json"        // the whole response is valid JSON
+	ConstraintRegex      ConstraintType = "regex"       // the response matches a pattern
+	ConstraintNoMarkdown ConstraintType = "no_markdown" // plain text without markdown syntax
+	ConstraintSections   ConstraintType = "sections"    // every named section is present
+)
+
+// constraintsSuffix names the constraints file kept next to a prompt template
+const constraintsSuffix = ".constraints.json"
+
+// markdownSyntax lists the markdown a no_markdown constraint rejects
+var markdownSyntax = []struct {
+	Name    string
+	Pattern *regexp.Regexp
+}{
+	{"heading", regexp.MustCompile(`(?m)^\s{0,3}#{1,6}\s`)},
+	{"code fence", regexp.MustCompile("(?m)^\\s*```")},
+	{"bold", regexp.MustCompile(`\*\*[^*\n]+\*\*|__[^_\n]+__`)},
+	{"list", regexp.MustCompile(`(?m)^\s*[-*+]\s+\S`)},
+	{"link", regexp.MustCompile(`\[[^\]\n]+\]\([^)\n]+\)`)},
+	{"inline code", regexp.MustCompile("`[^`\n]+`")},
+	{"table", regexp.MustCompile(`(?m)^\s*\|.*\|\s*$`)},
+}
+
+// FormatConstraint is a declarative format rule every response must follow
+type FormatConstraint struct {
+	Name     string         `json:"name"`
+	Type     ConstraintType `json:"type"`
+	Target   string         `json:"target"`   // main, sub or both (default)
+	Limit    int            `json:"limit"`    // max_words, max_lines and max_chars
+	Pattern  string         `json:"pattern"`  // regex
+	Sections []string       `json:"sections"` // sections: headings or "Name:" labels that must appear
+
+	pattern  *regexp.Regexp
+	sections []*regexp.Regexp
+}
+
+// ConstraintsFile is the on-disk format of a constraints file
+type ConstraintsFile struct {
+	Constraints []FormatConstraint `json:"constraints"`
+}
+
+// ConstraintViolationCount summarises the violations of a single constraint
+type ConstraintViolationCount struct {
+	Constraint string
+	Evaluated  int
+	Violated   int
+	Loops      []int // loops violating the constraint
+}
+
+// LoopCompliance is how one loop's response fared against the constraints
+type LoopCompliance struct {
+	Loop       int
+	Compliant  bool
+	Violations []string // "<constraint>: <reason>" for each violated constraint
+}
+
+// ConstraintSummary reports how closely one agent followed the format
+// constraints
+type ConstraintSummary struct {
+	Agent          string
+	LoopsEvaluated int
+	LoopsCompliant int
+	ComplianceRate float64 // share of loops meeting every constraint
+	Violations     []ConstraintViolationCount
+	Loops          []LoopCompliance
+}
+
+// LoadConstraints reads and validates a constraints file
+func LoadConstraints(filename string) ([]FormatConstraint, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read constraints file: %w", err)
+	}
+
+	var file ConstraintsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse constraints file: %w", err)
+	}
+
+	for i := range file.Constraints {
+		if err := file.Constraints[i].compile(i); err != nil {
+			return nil, err
+		}
+	}
+
+	return file.Constraints, nil
+}
+
+// TemplateConstraintsFile returns the constraints file belonging to a prompt
+// template, e.g. prompts/summary.constraints.json for prompts/summary.tmpl
+func TemplateConstraintsFile(template string) string {
+	return strings.TrimSuffix(template, filepath.Ext(template)) + constraintsSuffix
+}
+
+// LoadTemplateConstraints loads the constraints kept next to a prompt
+// template, returning nil when the template has none
+func LoadTemplateConstraints(template string) ([]FormatConstraint, error) {
+	if template == "" || template == "default" {
+		return nil, nil
+	}
+	filename := TemplateConstraintsFile(template)
+	if _, err := os.Stat(filename); err != nil {
+		return nil, nil
+	}
+	return LoadConstraints(filename)
+}
+
+// compile validates a constraint and prepares its patterns
+func (c *FormatConstraint) compile(index int) error {
+	if c.Name == "" {
+		c.Name = fmt.Sprintf("%s #%d", c.Type, index+1)
+	}
+
+	switch strings.ToLower(c.Target) {
+	case "", TargetBoth:
+		c.Target = TargetBoth
+	case TargetMain, TargetSub:
+		c.Target = strings.ToLower(c.Target)
+	default:
+		return fmt.Errorf("constraint %q: unknown target %q (expected main, sub or both)", c.Name, c.Target)
+	}
+
+	switch c.Type {
+	case ConstraintMaxWords, ConstraintMaxLines, ConstraintMaxChars:
+		if c.Limit <= 0 {
+			return fmt.Errorf("constraint %q: %s needs a positive limit", c.Name, c.Type)
+		}
+	case ConstraintJSON, ConstraintNoMarkdown:
+	case ConstraintRegex:
+		compiled, err := regexp.Compile(c.Pattern)
+		if err != nil {
+			return fmt.Errorf("constraint %q: invalid regex: %w", c.Name, err)
+		}
+		c.pattern = compiled
+	case ConstraintSections:
+		if len(c.Sections) == 0 {
+			return fmt.Errorf("constraint %q: sections needs at least one section", c.Name)
+		}
+		c.sections = c.sections[:0]
+		for _, section := range c.Sections {
+			c.sections = append(c.sections, regexp.MustCompile(
+				`(?im)^\s*(?:#{1,6}\s*)?(?:\*\*|__)?`+regexp.QuoteMeta(strings.TrimSpace(section))+`\s*(?:\*\*|__)?\s*(?::|$)`))
+		}
+	default:
+		return fmt.Errorf("constraint %q: unknown type %q", c.Name, c.Type)
+	}
+	return nil
+}
+
+// appliesTo reports whether the constraint checks the given agent's response
+func (c FormatConstraint) appliesTo(agent string) bool {
+	return c.Target == TargetBoth || c.Target == agent
+}
+
+// Check evaluates the constraint against a response
+func (c FormatConstraint) Check(response string) (bool, string) {
+	trimmed := strings.TrimSpace(response)
+
+	switch c.Type {
+	case ConstraintMaxWords:
+		if words := len(strings.Fields(trimmed)); words > c.Limit {
+			return false, fmt.Sprintf("%d words, limit %d", words, c.Limit)
+		}
+	case ConstraintMaxLines:
+		if lines := len(strings.Split(trimmed, "\n")); lines > c.Limit {
+			return false, fmt.Sprintf("%d lines, limit %d", lines, c.Limit)
+		}
+	case ConstraintMaxChars:
+		if chars := len([]rune(trimmed)); chars > c.Limit {
+			return false, fmt.Sprintf("%d characters, limit %d", chars, c.Limit)
+		}
+	case ConstraintJSON:
+		if !json.Valid([]byte(trimmed)) {
+			if _, ok := ExtractJSON(trimmed); ok {
+				return false, "JSON wrapped in other text"
+			}
+			return false, "not valid JSON"
+		}
+	case ConstraintRegex:
+		if c.pattern == nil || !c.pattern.MatchString(response) {
+			return false, fmt.Sprintf("no match for /%s/", c.Pattern)
+		}
+	case ConstraintNoMarkdown:
+		var found []string
+		for _, syntax := range markdownSyntax {
+			if syntax.Pattern.MatchString(response) {
+				found = append(found, syntax.Name)
+			}
+		}
+		if len(found) > 0 {
+			return false, "markdown " + strings.Join(found, ", ")
+		}
+	case ConstraintSections:
+		var missing []string
+		for i, section := range c.sections {
+			if !section.MatchString(response) {
+				missing = append(missing, c.Sections[i])
+			}
+		}
+		if len(missing) > 0 {
+			return false, "missing " + strings.Join(missing, ", ")
+		}
+	default:
+		return false, fmt.Sprintf("unknown constraint type %q", c.Type)
+	}
+	return true, ""
+}
+
+// CheckConstraints evaluates one agent's non-empty responses against the
+// constraints targeting it, returning nil when none apply
+func CheckConstraints(entries []LogEntry, constraints []FormatConstraint, agent string) *ConstraintSummary {
+	var applicable []FormatConstraint
+	for _, constraint := range constraints {
+		if constraint.appliesTo(agent) {
+			applicable = append(applicable, constraint)
+		}
+	}
+	if len(applicable) == 0 {
+		return nil
+	}
+
+	summary := &ConstraintSummary{Agent: agent}
+	violations := make([]ConstraintViolationCount, len(applicable))
+	for i, constraint := range applicable {
+		violations[i].Constraint = constraint.Name
+	}
+
+	for _, entry := range entries {
+		response := entry.SubAgentResponse
+		if agent == TargetMain {
+			response = entry.MainAgentResponse
+		}
+		if strings.TrimSpace(response) == "" {
+			continue
+		}
+
+		loop := LoopCompliance{Loop: entry.Loop, Compliant: true}
+		for i, constraint := range applicable {
+			violations[i].Evaluated++
+			if ok, message := constraint.Check(response); !ok {
+				loop.Compliant = false
+				loop.Violations = append(loop.Violations, constraint.Name+": "+message)
+				violations[i].Violated++
+				violations[i].Loops = append(violations[i].Loops, entry.Loop)
+			}
+		}
+
+		summary.LoopsEvaluated++
+		if loop.Compliant {
+			summary.LoopsCompliant++
+		}
+		summary.Loops = append(summary.Loops, loop)
+	}
+	if summary.LoopsEvaluated == 0 {
+		return nil
+	}
+	summary.ComplianceRate = float64(summary.LoopsCompliant) / float64(summary.LoopsEvaluated)
+
+	// Most frequently violated constraints first
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].Violated > violations[j].Violated
+	})
+	summary.Violations = violations
+
+	return summary
+}
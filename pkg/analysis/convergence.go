@@ -0,0 +1,57 @@
+package analysis
+
+// ConvergenceOptions configures the in-process convergence check used by
+// adaptive runs
+type ConvergenceOptions struct {
+	TargetMargin float64 // stop once the majority-share margin is at or below this
+	Clustering   ClusterOptions
+	Confidence   float64 // confidence level of the majority-share interval
+}
+
+// DefaultConvergenceOptions returns the options used by adaptive runs
+func DefaultConvergenceOptions() ConvergenceOptions {
+	return ConvergenceOptions{
+		TargetMargin: 0.1,
+		Clustering:   DefaultClusterOptions(),
+		Confidence:   DefaultBootstrapOptions().Confidence,
+	}
+}
+
+// Convergence is the outcome of a convergence check
+type Convergence struct {
+	Responses     int
+	Clusters      int
+	MajorityShare *ConfidenceInterval
+	Converged     bool
+}
+
+// CheckConvergence clusters the responses collected so far and reports
+// whether the confidence interval on the majority-cluster share is narrower
+// than the target margin. The interval is a Wilson score interval rather than
+// a bootstrap, which collapses to zero width when every loop agrees and would
+// stop a run after its first few loops.
+func CheckConvergence(responses []string, opts ConvergenceOptions) Convergence {
+	result := Convergence{Responses: len(responses)}
+	if len(responses) < 2 {
+		return result
+	}
+
+	// Adaptive runs have no metric configured, so clustering is lexical
+	matrix := CalculateSimilarityMatrix(responses)
+	clusters := ClusterResponsesWith(responses, matrix, opts.Clustering)
+	result.Clusters = len(clusters)
+	majority := 0
+	if len(clusters) > 0 {
+		majority = len(clusters[0].Responses)
+	}
+	result.MajorityShare = wilsonInterval(majority, len(responses), opts.Confidence)
+	result.Converged = result.MajorityShare.Margin() <= opts.TargetMargin
+
+	return result
+}
+
+// ExtractAgentResponses splits a raw response into the main agent's report of
+// what it told the subagent and the subagent's reply
+func ExtractAgentResponses(rawResponse string) (mainAgent, subAgent string) {
+	return extractBothAgentResponses(rawResponse)
+}
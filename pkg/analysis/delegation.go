@@ -0,0 +1,167 @@
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// defaultSubagentType is the agent the CLI runs when a Task call names none
+const defaultSubagentType = "general-purpose"
+
+// ToolCall is a tool invocation captured from the CLI's structured output
+type ToolCall struct {
+	ID           string `json:"id,omitempty"`
+	Name         string `json:"name"`
+	SubagentType string `json:"subagent_type,omitempty"`
+	Description  string `json:"description,omitempty"`
+	Parent       string `json:"parent_tool_use_id,omitempty"` // set for calls made inside a subagent
+}
+
+// IsSubagentCall reports whether the call delegated to a subagent
+func (c ToolCall) IsSubagentCall() bool {
+	return c.Name == "Task" || c.Name == "Agent"
+}
+
+// Agent returns the subagent a delegating call ran
+func (c ToolCall) Agent() string {
+	if c.SubagentType == "" {
+		return defaultSubagentType
+	}
+	return c.SubagentType
+}
+
+// FormatToolTrace writes tool calls as JSON lines for the log
+func FormatToolTrace(calls []ToolCall) string {
+	var lines []string
+	for _, call := range calls {
+		data, err := json.Marshal(call)
+		if err != nil {
+			continue
+		}
+		lines = append(lines, string(data))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ParseToolTrace reads tool calls written by FormatToolTrace
+func ParseToolTrace(content string) ([]ToolCall, error) {
+	calls := []ToolCall{}
+	for i, line := range strings.Split(content, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		var call ToolCall
+		if err := json.Unmarshal([]byte(line), &call); err != nil {
+			return calls, fmt.Errorf("tool call %d: %v", i+1, err)
+		}
+		calls = append(calls, call)
+	}
+	return calls, nil
+}
+
+// LoopDelegation is the subagent activity of one loop
+type LoopDelegation struct {
+	Loop          int
+	SubagentCalls int
+	Agents        []string // subagent of each delegating call, in order
+	Invoked       bool     // the expected agent was called
+	OtherAgents   bool     // a different agent was called
+}
+
+// AgentCallCount is how often one subagent was called
+type AgentCallCount struct {
+	Agent string
+	Calls int
+	Loops int
+}
+
+// DelegationSummary checks that loops delegated to the agent under test
+// rather than the main agent answering by itself
+type DelegationSummary struct {
+	Expected          string
+	TracedLoops       int // loops with a captured tool trace
+	UntracedLoops     int
+	LoopsInvoked      int
+	InvocationRate    float64 // share of traced loops calling the expected agent
+	NoCallLoops       []int   // traced loops without any subagent call
+	MismatchLoops     []int   // traced loops calling another agent instead of or as well as the expected one
+	RepeatedCallLoops []int   // traced loops calling the expected agent more than once
+	Agents            []AgentCallCount
+	Loops             []LoopDelegation
+}
+
+// SummarizeDelegation reports per loop whether the expected subagent was
+// called, returning nil when no entry has a tool trace. An empty expected
+// agent only counts calls.
+func SummarizeDelegation(entries []LogEntry, expected string) *DelegationSummary {
+	summary := &DelegationSummary{Expected: expected}
+	agents := make(map[string]*AgentCallCount)
+
+	for _, entry := range entries {
+		if !entry.ToolTrace {
+			summary.UntracedLoops++
+			continue
+		}
+		summary.TracedLoops++
+
+		loop := LoopDelegation{Loop: entry.Loop}
+		expectedCalls := 0
+		seen := make(map[string]bool)
+		for _, call := range entry.ToolCalls {
+			if !call.IsSubagentCall() || call.Parent != "" {
+				continue
+			}
+			agent := call.Agent()
+			loop.SubagentCalls++
+			loop.Agents = append(loop.Agents, agent)
+			if expected == "" || agent == expected {
+				expectedCalls++
+			} else {
+				loop.OtherAgents = true
+			}
+
+			count, ok := agents[agent]
+			if !ok {
+				count = &AgentCallCount{Agent: agent}
+				agents[agent] = count
+			}
+			count.Calls++
+			if !seen[agent] {
+				seen[agent] = true
+				count.Loops++
+			}
+		}
+		loop.Invoked = expectedCalls > 0
+
+		if loop.Invoked {
+			summary.LoopsInvoked++
+		}
+		if loop.SubagentCalls == 0 {
+			summary.NoCallLoops = append(summary.NoCallLoops, entry.Loop)
+		}
+		if loop.OtherAgents {
+			summary.MismatchLoops = append(summary.MismatchLoops, entry.Loop)
+		}
+		if expected != "" && expectedCalls > 1 {
+			summary.RepeatedCallLoops = append(summary.RepeatedCallLoops, entry.Loop)
+		}
+		summary.Loops = append(summary.Loops, loop)
+	}
+	if summary.TracedLoops == 0 {
+		return nil
+	}
+	summary.InvocationRate = float64(summary.LoopsInvoked) / float64(summary.TracedLoops)
+
+	for _, count := range agents {
+		summary.Agents = append(summary.Agents, *count)
+	}
+	sort.Slice(summary.Agents, func(i, j int) bool {
+		if summary.Agents[i].Calls != summary.Agents[j].Calls {
+			return summary.Agents[i].Calls > summary.Agents[j].Calls
+		}
+		return summary.Agents[i].Agent < summary.Agents[j].Agent
+	})
+	return summary
+}
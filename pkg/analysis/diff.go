@@ -0,0 +1,390 @@
+package analysis
+
+import (
+	"regexp"
+	"strings"
+)
+
+// DiffOp is the kind of change a diff segment represents
+type DiffOp string
+
+const (
+	DiffEqual  DiffOp = "equal"
+	DiffInsert DiffOp = "insert" // present only in the response
+	DiffDelete DiffOp = "delete" // present only in the reference
+)
+
+// DiffMode is the granularity of a diff
+type DiffMode string
+
+const (
+	DiffWords DiffMode = "word"
+	DiffLines DiffMode = "line"
+	DiffMixed DiffMode = "mixed" // prose by word, fenced code blocks by line
+)
+
+// DiffSegment is a run of tokens sharing the same change kind
+type DiffSegment struct {
+	Op   DiffOp
+	Text string
+	Code bool // whole lines of code, rendered with -/+ prefixes
+}
+
+// ResponseDiff is the difference between a reference response (usually the
+// dominant cluster's medoid) and another response
+type ResponseDiff struct {
+	Mode     DiffMode
+	Segments []DiffSegment
+	Inserted int // tokens only in the response
+	Deleted  int // tokens only in the reference
+}
+
+const (
+	ansiReset  = "\033[0m"
+	ansiDelete = "\033[9;31m" // red strikethrough
+	ansiInsert = "\033[32m"   // green
+)
+
+var (
+	diffTokenRegex = regexp.MustCompile(`\s+|\S+`)
+	codeFenceRegex = regexp.MustCompile("(?m)^\\s*```")
+)
+
+// diffToken is a word, a run of whitespace or, in code, a whole line
+type diffToken struct {
+	Text string
+	Code bool
+	key  int // equal for tokens that compare equal, assigned by diffTokens
+}
+
+// DiffResponses diffs a response against a reference word by word. When
+// either side contains a fenced code block, the blocks are diffed line by line
+// and the prose around them word by word.
+func DiffResponses(reference, response string) ResponseDiff {
+	if codeFenceRegex.MatchString(reference) || codeFenceRegex.MatchString(response) {
+		return diffTokens(DiffMixed, mixedTokens(reference), mixedTokens(response))
+	}
+	return WordDiff(reference, response)
+}
+
+// WordDiff diffs two texts by words, keeping the response's whitespace
+func WordDiff(reference, response string) ResponseDiff {
+	return diffTokens(DiffWords, wordTokens(reference), wordTokens(response))
+}
+
+// LineDiff diffs two texts line by line, ignoring trailing whitespace
+func LineDiff(reference, response string) ResponseDiff {
+	return diffTokens(DiffLines, lineTokens(reference), lineTokens(response))
+}
+
+// wordTokens splits prose into words and runs of whitespace
+func wordTokens(text string) []diffToken {
+	var tokens []diffToken
+	for _, word := range diffTokenRegex.FindAllString(text, -1) {
+		tokens = append(tokens, diffToken{Text: word})
+	}
+	return tokens
+}
+
+// lineTokens splits text into lines of code
+func lineTokens(text string) []diffToken {
+	var tokens []diffToken
+	for _, line := range splitLines(text) {
+		tokens = append(tokens, diffToken{Text: line, Code: true})
+	}
+	return tokens
+}
+
+// mixedTokens splits fenced code blocks, fences included, into lines and the
+// prose between them into words. An unclosed fence runs to the end.
+func mixedTokens(text string) []diffToken {
+	var tokens []diffToken
+	var prose strings.Builder
+	inBlock := false
+	for _, line := range splitLines(text) {
+		switch {
+		case !inBlock && fenceOpenRegex.MatchString(line):
+			inBlock = true
+		case inBlock:
+			inBlock = !fenceCloseRegex.MatchString(strings.TrimRight(line, "\r\n"))
+		default:
+			prose.WriteString(line)
+			continue
+		}
+		tokens = append(tokens, wordTokens(prose.String())...)
+		prose.Reset()
+		tokens = append(tokens, diffToken{Text: line, Code: true})
+	}
+	return append(tokens, wordTokens(prose.String())...)
+}
+
+// splitLines splits text into lines that keep their newline
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// assignKeys numbers the distinct tokens of both sides so they compare as
+// integers: lines of code ignoring trailing whitespace, and words exactly,
+// with any run of whitespace matching any other
+func assignKeys(sides ...[]diffToken) {
+	keys := make(map[string]int)
+	for _, tokens := range sides {
+		for i, token := range tokens {
+			normalized := "w" + token.Text
+			switch {
+			case token.Code:
+				normalized = "c" + strings.TrimRight(token.Text, " \t\r\n")
+			case strings.TrimSpace(token.Text) == "":
+				normalized = "s"
+			}
+			key, ok := keys[normalized]
+			if !ok {
+				key = len(keys)
+				keys[normalized] = key
+			}
+			tokens[i].key = key
+		}
+	}
+}
+
+// diffTokens finds the longest common subsequence of the reference and
+// response tokens and groups the rest into deletions and insertions
+func diffTokens(mode DiffMode, a, b []diffToken) ResponseDiff {
+	assignKeys(a, b)
+	edits := lcsEdits(a, b, nil)
+
+	diff := ResponseDiff{Mode: mode}
+	var deleted, inserted []string
+	pendingCode := false
+	flush := func() {
+		if len(deleted) > 0 {
+			diff.Segments = appendSegment(diff.Segments, DiffSegment{DiffDelete, strings.Join(deleted, ""), pendingCode})
+		}
+		if len(inserted) > 0 {
+			diff.Segments = appendSegment(diff.Segments, DiffSegment{DiffInsert, strings.Join(inserted, ""), pendingCode})
+		}
+		deleted, inserted = nil, nil
+	}
+
+	// Group each run of changes into one deletion and one insertion so
+	// replaced phrases read as a whole
+	for k, edit := range edits {
+		if edit.Code != pendingCode {
+			flush()
+			pendingCode = edit.Code
+		}
+		isSpace := strings.TrimSpace(edit.Text) == ""
+		switch edit.Op {
+		case DiffEqual:
+			if isSpace && !edit.Code && (len(deleted) > 0 || len(inserted) > 0) && k+1 < len(edits) && edits[k+1].Op != DiffEqual {
+				// Whitespace between two changes belongs to the change
+				deleted = append(deleted, edit.Text)
+				inserted = append(inserted, edit.Text)
+				continue
+			}
+			flush()
+			diff.Segments = appendSegment(diff.Segments, edit)
+		case DiffDelete:
+			deleted = append(deleted, edit.Text)
+			if !isSpace {
+				diff.Deleted++
+			}
+		case DiffInsert:
+			inserted = append(inserted, edit.Text)
+			if !isSpace {
+				diff.Inserted++
+			}
+		}
+	}
+	flush()
+
+	return diff
+}
+
+// lcsEdits appends the edits turning a into b to edits, using Hirschberg's
+// divide and conquer so memory stays linear in the token count. Equal tokens
+// keep the response's text.
+func lcsEdits(a, b []diffToken, edits []DiffSegment) []DiffSegment {
+	// Common prefixes and suffixes need no search
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix].key == b[prefix].key {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix].key == b[len(b)-1-suffix].key {
+		suffix++
+	}
+	for _, token := range b[:prefix] {
+		edits = append(edits, DiffSegment{DiffEqual, token.Text, token.Code})
+	}
+	tail := b[len(b)-suffix:]
+	a, b = a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	switch {
+	case len(a) == 0:
+		for _, token := range b {
+			edits = append(edits, DiffSegment{DiffInsert, token.Text, token.Code})
+		}
+	case len(b) == 0:
+		for _, token := range a {
+			edits = append(edits, DiffSegment{DiffDelete, token.Text, token.Code})
+		}
+	case len(a) == 1:
+		// Trimming left no match at either end, so a[0] is a substitution
+		// unless it matches somewhere in the middle
+		match := -1
+		for j := range b {
+			if a[0].key == b[j].key {
+				match = j
+				break
+			}
+		}
+		if match < 0 {
+			edits = append(edits, DiffSegment{DiffDelete, a[0].Text, a[0].Code})
+		}
+		for j, token := range b {
+			op := DiffInsert
+			if j == match {
+				op = DiffEqual
+			}
+			edits = append(edits, DiffSegment{op, token.Text, token.Code})
+		}
+	default:
+		mid := len(a) / 2
+		forward := lcsLengths(a[:mid], b, false)
+		backward := lcsLengths(a[mid:], b, true)
+		split, best := 0, -1
+		for j := 0; j <= len(b); j++ {
+			if length := forward[j] + backward[len(b)-j]; length > best {
+				split, best = j, length
+			}
+		}
+		edits = lcsEdits(a[:mid], b[:split], edits)
+		edits = lcsEdits(a[mid:], b[split:], edits)
+	}
+
+	for _, token := range tail {
+		edits = append(edits, DiffSegment{DiffEqual, token.Text, token.Code})
+	}
+	return edits
+}
+
+// lcsLengths returns the length of the longest common subsequence of a and
+// every prefix of b, or with reverse set, of the reversed sequences, so entry
+// j covers the last j tokens of b. Only two rows are kept.
+func lcsLengths(a, b []diffToken, reverse bool) []int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	at := func(tokens []diffToken, i int) diffToken {
+		if reverse {
+			return tokens[len(tokens)-1-i]
+		}
+		return tokens[i]
+	}
+	for i := range a {
+		x := at(a, i)
+		for j := range b {
+			if x.key == at(b, j).key {
+				current[j+1] = previous[j] + 1
+			} else {
+				current[j+1] = max(current[j], previous[j+1])
+			}
+		}
+		previous, current = current, previous
+	}
+	return previous
+}
+
+// appendSegment appends a segment, merging it into the last one when both
+// are of the same kind
+func appendSegment(segments []DiffSegment, segment DiffSegment) []DiffSegment {
+	if n := len(segments); n > 0 && segments[n-1].Op == segment.Op && segments[n-1].Code == segment.Code {
+		segments[n-1].Text += segment.Text
+		return segments
+	}
+	return append(segments, segment)
+}
+
+// Changed reports whether the two sides differ
+func (d ResponseDiff) Changed() bool {
+	return d.Inserted > 0 || d.Deleted > 0
+}
+
+// ANSI renders the diff with terminal colors: deleted words in red
+// strikethrough and inserted words in green, and lines of code with -/+
+// prefixes in the same colors
+func (d ResponseDiff) ANSI() string {
+	return d.render(func(op DiffOp, text string, code bool) string {
+		switch {
+		case code && op == DiffDelete:
+			return ansiDelete + "- " + text + ansiReset
+		case code && op == DiffInsert:
+			return ansiInsert + "+ " + text + ansiReset
+		case code:
+			return "  " + text
+		case op == DiffDelete:
+			return ansiDelete + text + ansiReset
+		case op == DiffInsert:
+			return ansiInsert + text + ansiReset
+		}
+		return text
+	})
+}
+
+// Markers renders the diff as plain text for file reports, marking words as
+// [-deleted-] and {+inserted+}, and lines of code with -/+ prefixes
+func (d ResponseDiff) Markers() string {
+	return d.render(func(op DiffOp, text string, code bool) string {
+		switch {
+		case code && op == DiffDelete:
+			return "- " + text
+		case code && op == DiffInsert:
+			return "+ " + text
+		case code:
+			return "  " + text
+		case op == DiffDelete:
+			return "[-" + text + "-]"
+		case op == DiffInsert:
+			return "{+" + text + "+}"
+		}
+		return text
+	})
+}
+
+// render formats prose segments whole and code segments line by line, each
+// code line on a line of its own
+func (d ResponseDiff) render(format func(op DiffOp, text string, code bool) string) string {
+	var out strings.Builder
+	for _, segment := range d.Segments {
+		if !segment.Code {
+			out.WriteString(format(segment.Op, segment.Text, false))
+			continue
+		}
+		for _, line := range splitLines(segment.Text) {
+			if text := out.String(); text != "" && !strings.HasSuffix(text, "\n") {
+				out.WriteString("\n")
+			}
+			out.WriteString(format(segment.Op, strings.TrimRight(line, "\r\n"), true) + "\n")
+		}
+	}
+	return strings.TrimSuffix(out.String(), "\n")
+}
+
+// diffReference picks the response an outlier is compared against: the medoid
+// of the dominant cluster, or of the next largest cluster when the response is
+// the dominant medoid itself. It returns -1 if there is nothing to compare with.
+func diffReference(index int, clusters []ResponseCluster) int {
+	for _, cluster := range clusters {
+		if cluster.Medoid != index {
+			return cluster.Medoid
+		}
+	}
+	return -1
+}
@@ -0,0 +1,127 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWordDiff(t *testing.T) {
+	tests := []struct {
+		name      string
+		reference string
+		response  string
+		markers   string
+		deleted   int
+		inserted  int
+	}{
+		{
+			name:      "identical",
+			reference: "the cat sat",
+			response:  "the cat sat",
+			markers:   "the cat sat",
+		},
+		{
+			name:      "replaced word",
+			reference: "the cat sat",
+			response:  "the dog sat",
+			markers:   "the [-cat-]{+dog+} sat",
+			deleted:   1,
+			inserted:  1,
+		},
+		{
+			name:      "replaced phrase reads as a whole",
+			reference: "a big red ball",
+			response:  "a small blue ball",
+			markers:   "a [-big red-]{+small blue+} ball",
+			deleted:   2,
+			inserted:  2,
+		},
+		{
+			name:      "appended word",
+			reference: "hello",
+			response:  "hello world",
+			markers:   "hello{+ world+}",
+			inserted:  1,
+		},
+		{
+			name:      "whitespace runs match",
+			reference: "one  two",
+			response:  "one two",
+			markers:   "one two",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := WordDiff(tt.reference, tt.response)
+			if got := diff.Markers(); got != tt.markers {
+				t.Errorf("Markers() = %q, want %q", got, tt.markers)
+			}
+			if diff.Deleted != tt.deleted || diff.Inserted != tt.inserted {
+				t.Errorf("deleted/inserted = %d/%d, want %d/%d", diff.Deleted, diff.Inserted, tt.deleted, tt.inserted)
+			}
+		})
+	}
+}
+
+func TestDiffResponsesMixed(t *testing.T) {
+	reference := "Use this helper:\n```go\nx := 1\ny := 2\n```\nIt adds numbers."
+	response := "Use that helper:\n```go\nx := 1\ny := 3\n```\nIt adds numbers."
+
+	diff := DiffResponses(reference, response)
+	if diff.Mode != DiffMixed {
+		t.Fatalf("Mode = %q, want %q", diff.Mode, DiffMixed)
+	}
+	want := "Use [-this-]{+that+} helper:\n" +
+		"  ```go\n" +
+		"  x := 1\n" +
+		"- y := 2\n" +
+		"+ y := 3\n" +
+		"  ```\n" +
+		"It adds numbers."
+	if got := diff.Markers(); got != want {
+		t.Errorf("Markers() =\n%s\nwant\n%s", got, want)
+	}
+	if diff.Deleted != 2 || diff.Inserted != 2 {
+		t.Errorf("deleted/inserted = %d/%d, want 2/2", diff.Deleted, diff.Inserted)
+	}
+}
+
+func TestDiffResponsesProseOnly(t *testing.T) {
+	if diff := DiffResponses("a b", "a c"); diff.Mode != DiffWords {
+		t.Errorf("Mode = %q, want %q", diff.Mode, DiffWords)
+	}
+}
+
+func TestWordDiffLongResponses(t *testing.T) {
+	// Long enough that a full edit table would take hundreds of megabytes
+	words := make([]string, 6000)
+	for i := range words {
+		words[i] = "w" + strings.Repeat("x", i%7)
+	}
+	reference := strings.Join(words, " ")
+	for i := 250; i < len(words); i += 500 {
+		words[i] = "changed"
+	}
+	response := strings.Join(words, " ") + " end"
+
+	diff := WordDiff(reference, response)
+	if diff.Deleted != 12 || diff.Inserted != 13 {
+		t.Errorf("deleted/inserted = %d/%d, want 12/13", diff.Deleted, diff.Inserted)
+	}
+	var oldText, newText strings.Builder
+	for _, segment := range diff.Segments {
+		if segment.Op != DiffInsert {
+			oldText.WriteString(segment.Text)
+		}
+		if segment.Op != DiffDelete {
+			newText.WriteString(segment.Text)
+		}
+	}
+	if oldText.String() != reference {
+		t.Error("equal and deleted segments do not rebuild the reference")
+	}
+	if newText.String() != response {
+		t.Error("equal and inserted segments do not rebuild the response")
+	}
+}
@@ -0,0 +1,232 @@
+package analysis
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Embedder converts texts into embedding vectors, one per text in order
+type Embedder interface {
+	Embed(texts []string) ([][]float64, error)
+}
+
+// DefaultEmbeddingURL is the OpenAI-compatible endpoint served by a local
+// Ollama install
+const DefaultEmbeddingURL = "http://localhost:11434/v1/embeddings"
+
+// HTTPEmbedder calls an OpenAI-compatible /v1/embeddings endpoint, such as a
+// llama.cpp or Ollama server
+type HTTPEmbedder struct {
+	URL       string
+	Model     string
+	APIKey    string // sent as a bearer token when set
+	BatchSize int    // texts per request, 0 sends all at once
+	Client    *http.Client
+}
+
+// NewHTTPEmbedder returns an embedder for the given endpoint and model
+func NewHTTPEmbedder(url, model string) *HTTPEmbedder {
+	return &HTTPEmbedder{
+		URL:       url,
+		Model:     model,
+		BatchSize: 64,
+		Client:    &http.Client{Timeout: 2 * time.Minute},
+	}
+}
+
+type embeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float64 `json:"embedding"`
+	} `json:"data"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Embed implements Embedder
+func (e *HTTPEmbedder) Embed(texts []string) ([][]float64, error) {
+	batch := e.BatchSize
+	if batch <= 0 {
+		batch = len(texts)
+	}
+
+	vectors := make([][]float64, 0, len(texts))
+	for start := 0; start < len(texts); start += batch {
+		end := start + batch
+		if end > len(texts) {
+			end = len(texts)
+		}
+		embedded, err := e.embedBatch(texts[start:end])
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, embedded...)
+	}
+	return vectors, nil
+}
+
+// embedBatch sends a single embeddings request
+func (e *HTTPEmbedder) embedBatch(texts []string) ([][]float64, error) {
+	body, err := json.Marshal(embeddingRequest{Model: e.Model, Input: texts})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, e.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.APIKey)
+	}
+
+	client := e.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("embeddings request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embeddings response: %w", err)
+	}
+
+	var parsed embeddingResponse
+	parseErr := json.Unmarshal(data, &parsed)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		if parseErr == nil && parsed.Error != nil {
+			return nil, fmt.Errorf("embeddings endpoint returned %d: %s", resp.StatusCode, parsed.Error.Message)
+		}
+		return nil, fmt.Errorf("embeddings endpoint returned %d", resp.StatusCode)
+	}
+	if parseErr != nil {
+		return nil, fmt.Errorf("invalid embeddings response: %w", parseErr)
+	}
+	if len(parsed.Data) != len(texts) {
+		return nil, fmt.Errorf("embeddings endpoint returned %d vectors for %d inputs", len(parsed.Data), len(texts))
+	}
+
+	vectors := make([][]float64, len(texts))
+	for _, item := range parsed.Data {
+		if item.Index < 0 || item.Index >= len(texts) {
+			return nil, fmt.Errorf("embeddings endpoint returned out of range index %d", item.Index)
+		}
+		vectors[item.Index] = item.Embedding
+	}
+	return vectors, nil
+}
+
+// CachedEmbedder stores embeddings on disk keyed by a hash of the model and
+// text, so repeated analyses only embed new responses
+type CachedEmbedder struct {
+	Embedder Embedder
+	Dir      string
+	Model    string // part of the cache key, as vectors differ between models
+}
+
+// NewCachedEmbedder wraps an embedder with a disk cache in dir
+func NewCachedEmbedder(embedder Embedder, dir, model string) *CachedEmbedder {
+	return &CachedEmbedder{Embedder: embedder, Dir: dir, Model: model}
+}
+
+// DefaultEmbeddingCacheDir returns the per-user cache directory for embeddings
+func DefaultEmbeddingCacheDir() string {
+	return defaultCacheDir("embeddings")
+}
+
+// defaultCacheDir returns a named directory under the per-user cache
+func defaultCacheDir(name string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "agent-reliability-tests", name)
+}
+
+// Embed implements Embedder
+func (c *CachedEmbedder) Embed(texts []string) ([][]float64, error) {
+	vectors := make([][]float64, len(texts))
+	var missing []string
+	var missingIndex []int
+
+	for i, text := range texts {
+		if vector, ok := c.load(text); ok {
+			vectors[i] = vector
+			continue
+		}
+		missing = append(missing, text)
+		missingIndex = append(missingIndex, i)
+	}
+	if len(missing) == 0 {
+		return vectors, nil
+	}
+
+	embedded, err := c.Embedder.Embed(missing)
+	if err != nil {
+		return nil, err
+	}
+	if len(embedded) != len(missing) {
+		return nil, fmt.Errorf("embedder returned %d vectors for %d texts", len(embedded), len(missing))
+	}
+
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create embedding cache: %w", err)
+	}
+	for k, vector := range embedded {
+		vectors[missingIndex[k]] = vector
+		if err := c.store(missing[k], vector); err != nil {
+			return nil, err
+		}
+	}
+	return vectors, nil
+}
+
+// cachePath returns the cache file for a text
+func (c *CachedEmbedder) cachePath(text string) string {
+	sum := sha256.Sum256([]byte(c.Model + "\x00" + text))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// load reads a cached vector, treating unreadable entries as misses
+func (c *CachedEmbedder) load(text string) ([]float64, bool) {
+	data, err := os.ReadFile(c.cachePath(text))
+	if err != nil {
+		return nil, false
+	}
+	var vector []float64
+	if err := json.Unmarshal(data, &vector); err != nil || len(vector) == 0 {
+		return nil, false
+	}
+	return vector, true
+}
+
+// store writes a vector to the cache
+func (c *CachedEmbedder) store(text string, vector []float64) error {
+	data, err := json.Marshal(vector)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(c.cachePath(text), data, 0644); err != nil {
+		return fmt.Errorf("failed to write embedding cache: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package analysis
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// stubEmbeddingServer answers embedding requests with one-dimensional vectors
+// holding each input's length, listed in reverse order, and records the size
+// of every batch it receives
+func stubEmbeddingServer(t *testing.T) (*httptest.Server, func() []int) {
+	t.Helper()
+	var mu sync.Mutex
+	var batches []int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request embeddingRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if request.Model != "stub-model" {
+			t.Errorf("model = %q, want stub-model", request.Model)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q, want bearer token", got)
+		}
+		mu.Lock()
+		batches = append(batches, len(request.Input))
+		mu.Unlock()
+
+		type item struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		}
+		var response struct {
+			Data []item `json:"data"`
+		}
+		for i := len(request.Input) - 1; i >= 0; i-- {
+			response.Data = append(response.Data, item{Index: i, Embedding: []float64{float64(len(request.Input[i]))}})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []int {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]int(nil), batches...)
+	}
+}
+
+func newStubEmbedder(url string) *HTTPEmbedder {
+	embedder := NewHTTPEmbedder(url, "stub-model")
+	embedder.APIKey = "secret"
+	embedder.BatchSize = 2
+	return embedder
+}
+
+func TestHTTPEmbedderBatchesAndOrders(t *testing.T) {
+	server, batches := stubEmbeddingServer(t)
+	texts := []string{"a", "bb", "ccc", "dddd", "eeeee"}
+
+	vectors, err := newStubEmbedder(server.URL).Embed(texts)
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+	for i, text := range texts {
+		if len(vectors[i]) != 1 || vectors[i][0] != float64(len(text)) {
+			t.Errorf("vector %d = %v, want [%d]", i, vectors[i], len(text))
+		}
+	}
+	if got := batches(); len(got) != 3 || got[0] != 2 || got[1] != 2 || got[2] != 1 {
+		t.Errorf("batch sizes = %v, want [2 2 1]", got)
+	}
+}
+
+func TestHTTPEmbedderErrorStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{"json error", http.StatusUnauthorized, `{"error": {"message": "bad key"}}`, "returned 401: bad key"},
+		{"plain text", http.StatusBadGateway, "upstream down", "returned 502"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			_, err := newStubEmbedder(server.URL).Embed([]string{"text"})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Embed() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCachedEmbedderServesRepeatsFromDisk(t *testing.T) {
+	server, batches := stubEmbeddingServer(t)
+	cached := NewCachedEmbedder(newStubEmbedder(server.URL), t.TempDir(), "stub-model")
+
+	first, err := cached.Embed([]string{"one", "three"})
+	if err != nil {
+		t.Fatalf("first Embed() error = %v", err)
+	}
+	requests := len(batches())
+
+	second, err := cached.Embed([]string{"three", "one"})
+	if err != nil {
+		t.Fatalf("second Embed() error = %v", err)
+	}
+	if got := len(batches()); got != requests {
+		t.Errorf("second call made %d requests, want it served from the cache", got-requests)
+	}
+	if second[0][0] != first[1][0] || second[1][0] != first[0][0] {
+		t.Errorf("cached vectors %v do not match %v", second, first)
+	}
+
+	// Only the new text is sent
+	if _, err := cached.Embed([]string{"one", "fifteen"}); err != nil {
+		t.Fatalf("third Embed() error = %v", err)
+	}
+	if got := batches(); got[len(got)-1] != 1 {
+		t.Errorf("last batch size = %d, want 1", got[len(got)-1])
+	}
+}
@@ -0,0 +1,117 @@
+package analysis
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ErrorClass is the category of a stderr message
+type ErrorClass string
+
+const (
+	ErrorRateLimit  ErrorClass = "rate_limit"
+	ErrorOverloaded ErrorClass = "overloaded"
+	ErrorTimeout    ErrorClass = "timeout"
+	ErrorAuth       ErrorClass = "auth"
+	ErrorNetwork    ErrorClass = "network"
+	ErrorPermission ErrorClass = "permission"
+	ErrorNotFound   ErrorClass = "not_found"
+	ErrorUsage      ErrorClass = "usage"
+	ErrorOther      ErrorClass = "other"
+)
+
+// errorClassRules are checked in order; the first matching rule wins
+var errorClassRules = []struct {
+	Class   ErrorClass
+	Pattern *regexp.Regexp
+}{
+	{ErrorRateLimit, regexp.MustCompile(`(?i)rate.?limit|too many requests|\b429\b|quota`)},
+	{ErrorOverloaded, regexp.MustCompile(`(?i)overloaded|\b529\b|\b503\b|service unavailable|capacity`)},
+	{ErrorTimeout, regexp.MustCompile(`(?i)time(d)?.?out|deadline exceeded`)},
+	{ErrorAuth, regexp.MustCompile(`(?i)unauthori[sz]ed|authenticat|api.?key|\b401\b|\b403\b|forbidden|credential|\blog ?in\b`)},
+	{ErrorNetwork, regexp.MustCompile(`(?i)connection|network|ECONNRESET|ECONNREFUSED|ENOTFOUND|\bdns\b|socket|\btls\b|\bEOF\b`)},
+	{ErrorPermission, regexp.MustCompile(`(?i)permission|not allowed|denied|EACCES`)},
+	{ErrorNotFound, regexp.MustCompile(`(?i)not found|no such|unknown agent|ENOENT|\b404\b`)},
+	{ErrorUsage, regexp.MustCompile(`(?i)usage:|unknown (option|flag|command)|invalid (argument|option)`)},
+}
+
+// ErrorClassCount is how often one class of error occurred
+type ErrorClassCount struct {
+	Class    ErrorClass
+	Messages int   // stderr lines in this class
+	Loops    []int // loops with at least one message in this class
+	Example  string
+}
+
+// ErrorSummary categorises the stderr output of a run
+type ErrorSummary struct {
+	Entries            int
+	LoopsWithErrors    int
+	ErrorRate          float64 // share of loops with stderr output
+	Classes            []ErrorClassCount
+	EmptyResponseLoops []int // loops that produced no output at all
+}
+
+// ClassifyError assigns a stderr message to an error class
+func ClassifyError(message string) ErrorClass {
+	for _, rule := range errorClassRules {
+		if rule.Pattern.MatchString(message) {
+			return rule.Class
+		}
+	}
+	return ErrorOther
+}
+
+// SummarizeErrors classifies every stderr line and flags loops with empty
+// responses, returning nil for an empty run
+func SummarizeErrors(entries []LogEntry) *ErrorSummary {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	summary := &ErrorSummary{Entries: len(entries)}
+	classes := make(map[ErrorClass]*ErrorClassCount)
+
+	for _, entry := range entries {
+		if strings.TrimSpace(entry.RawResponse) == "" {
+			summary.EmptyResponseLoops = append(summary.EmptyResponseLoops, entry.Loop)
+		}
+		if strings.TrimSpace(entry.Errors) == "" {
+			continue
+		}
+		summary.LoopsWithErrors++
+
+		seen := make(map[ErrorClass]bool)
+		for _, line := range strings.Split(entry.Errors, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			class := ClassifyError(line)
+			count, ok := classes[class]
+			if !ok {
+				count = &ErrorClassCount{Class: class, Example: line}
+				classes[class] = count
+			}
+			count.Messages++
+			if !seen[class] {
+				seen[class] = true
+				count.Loops = append(count.Loops, entry.Loop)
+			}
+		}
+	}
+	summary.ErrorRate = float64(summary.LoopsWithErrors) / float64(len(entries))
+
+	for _, count := range classes {
+		summary.Classes = append(summary.Classes, *count)
+	}
+	sort.Slice(summary.Classes, func(i, j int) bool {
+		if len(summary.Classes[i].Loops) != len(summary.Classes[j].Loops) {
+			return len(summary.Classes[i].Loops) > len(summary.Classes[j].Loops)
+		}
+		return summary.Classes[i].Class < summary.Classes[j].Class
+	})
+
+	return summary
+}
@@ -0,0 +1,247 @@
+package analysis
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// rootFieldPath names a JSON document that is not an object
+const rootFieldPath = "$"
+
+// TypeCount is how many loops a field had one JSON type in
+type TypeCount struct {
+	Type  string
+	Loops int
+}
+
+// FieldConsistency is how consistently one JSON field appeared across loops
+type FieldConsistency struct {
+	Path           string // dotted path from the document root, e.g. "result.items"
+	Present        int    // loops with the field
+	PresenceRate   float64
+	MissingLoops   []int
+	Types          []TypeCount // most common first
+	TypeStability  float64     // share of present loops with the most common type
+	Kind           string      // most common type
+	MajorityValue  string      // scalars: the most common value as JSON
+	ValueAgreement float64     // scalars: share of present loops with the majority value
+	DistinctValues int         // scalars: number of different values
+	DeviantLoops   []int       // scalars: present loops with another value
+	SetOverlap     float64     // arrays: mean pairwise Jaccard similarity of the elements
+	Consistency    float64     // presence * type stability * agreement or overlap
+}
+
+// FieldSummary reports per-field consistency of JSON responses, so the flaky
+// part of a structured answer is visible instead of a whole-blob similarity
+type FieldSummary struct {
+	Responses          int
+	JSONLoops          int
+	NonJSONLoops       []int
+	Fields             []FieldConsistency // least consistent first
+	FlakyFields        int                // fields with consistency below 1
+	MeanConsistency    float64
+	StructureAgreement float64 // share of JSON loops with the most common set of fields
+}
+
+// AnalyzeJSONFields compares the fields of the JSON in each response,
+// returning nil unless at least two responses, and at least half of them,
+// contain JSON
+func AnalyzeJSONFields(entries []LogEntry) *FieldSummary {
+	summary := &FieldSummary{Responses: len(entries)}
+	type document struct {
+		loop   int
+		fields map[string]interface{}
+	}
+	var documents []document
+
+	for _, entry := range entries {
+		value, ok := ExtractJSON(getResponseFromEntry(entry))
+		if !ok {
+			summary.NonJSONLoops = append(summary.NonJSONLoops, entry.Loop)
+			continue
+		}
+		fields := make(map[string]interface{})
+		flattenJSON(rootFieldPath, value, fields)
+		documents = append(documents, document{loop: entry.Loop, fields: fields})
+	}
+	summary.JSONLoops = len(documents)
+	if summary.JSONLoops < 2 || 2*summary.JSONLoops < summary.Responses {
+		return nil
+	}
+
+	paths := make(map[string]bool)
+	structures := make(map[string]int)
+	for _, doc := range documents {
+		keys := make([]string, 0, len(doc.fields))
+		for path := range doc.fields {
+			paths[path] = true
+			keys = append(keys, path)
+		}
+		sort.Strings(keys)
+		structures[strings.Join(keys, "\n")]++
+	}
+	largest := 0
+	for _, count := range structures {
+		if count > largest {
+			largest = count
+		}
+	}
+	summary.StructureAgreement = float64(largest) / float64(summary.JSONLoops)
+
+	for path := range paths {
+		field := FieldConsistency{Path: path}
+		var loops []int
+		var values []interface{}
+		for _, doc := range documents {
+			value, ok := doc.fields[path]
+			if !ok {
+				field.MissingLoops = append(field.MissingLoops, doc.loop)
+				continue
+			}
+			loops = append(loops, doc.loop)
+			values = append(values, value)
+		}
+		field.Present = len(values)
+		field.PresenceRate = float64(field.Present) / float64(summary.JSONLoops)
+		measureField(&field, loops, values)
+
+		summary.Fields = append(summary.Fields, field)
+		summary.MeanConsistency += field.Consistency
+		if field.Consistency < 1-1e-9 {
+			summary.FlakyFields++
+		}
+	}
+	if len(summary.Fields) > 0 {
+		summary.MeanConsistency /= float64(len(summary.Fields))
+	}
+
+	sort.Slice(summary.Fields, func(i, j int) bool {
+		if summary.Fields[i].Consistency != summary.Fields[j].Consistency {
+			return summary.Fields[i].Consistency < summary.Fields[j].Consistency
+		}
+		return summary.Fields[i].Path < summary.Fields[j].Path
+	})
+	return summary
+}
+
+// flattenJSON records every field of a decoded document by its dotted path.
+// Objects are recursed into; arrays and scalars are leaves.
+func flattenJSON(path string, value interface{}, fields map[string]interface{}) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		fields[path] = value
+		return
+	}
+	if path != rootFieldPath {
+		fields[path] = value
+	}
+	for key, child := range object {
+		childPath := key
+		if path != rootFieldPath {
+			childPath = path + "." + key
+		}
+		flattenJSON(childPath, child, fields)
+	}
+}
+
+// measureField computes the type stability and value agreement of the values
+// a field took in the loops it was present in
+func measureField(field *FieldConsistency, loops []int, values []interface{}) {
+	if len(values) == 0 {
+		return
+	}
+
+	types := make(map[string]int)
+	for _, value := range values {
+		types[jsonKind(value)]++
+	}
+	for kind, count := range types {
+		field.Types = append(field.Types, TypeCount{Type: kind, Loops: count})
+	}
+	sort.Slice(field.Types, func(i, j int) bool {
+		if field.Types[i].Loops != field.Types[j].Loops {
+			return field.Types[i].Loops > field.Types[j].Loops
+		}
+		return field.Types[i].Type < field.Types[j].Type
+	})
+	field.Kind = field.Types[0].Type
+	field.TypeStability = float64(field.Types[0].Loops) / float64(len(values))
+
+	agreement := 1.0
+	switch field.Kind {
+	case "object":
+		// Nested fields are measured separately
+	case "array":
+		field.SetOverlap = meanSetOverlap(values)
+		agreement = field.SetOverlap
+	default:
+		counts := make(map[string]int)
+		canonical := make([]string, len(values))
+		for i, value := range values {
+			canonical[i] = canonicalJSON(value)
+			counts[canonical[i]]++
+		}
+		field.DistinctValues = len(counts)
+		for value, count := range counts {
+			if count > counts[field.MajorityValue] || (count == counts[field.MajorityValue] && value < field.MajorityValue) {
+				field.MajorityValue = value
+			}
+		}
+		for i, value := range canonical {
+			if value != field.MajorityValue {
+				field.DeviantLoops = append(field.DeviantLoops, loops[i])
+			}
+		}
+		field.ValueAgreement = float64(counts[field.MajorityValue]) / float64(len(values))
+		agreement = field.ValueAgreement
+	}
+
+	field.Consistency = field.PresenceRate * field.TypeStability * agreement
+}
+
+// meanSetOverlap is the mean pairwise Jaccard similarity of the elements of
+// the array values, treating anything else as an empty set
+func meanSetOverlap(values []interface{}) float64 {
+	sets := make([]map[string]bool, len(values))
+	for i, value := range values {
+		sets[i] = make(map[string]bool)
+		if array, ok := value.([]interface{}); ok {
+			for _, element := range array {
+				sets[i][canonicalJSON(element)] = true
+			}
+		}
+	}
+	if len(sets) < 2 {
+		return 1
+	}
+
+	total, pairs := 0.0, 0
+	for i := 0; i < len(sets); i++ {
+		for j := i + 1; j < len(sets); j++ {
+			total += termJaccard(sets[i], sets[j])
+			pairs++
+		}
+	}
+	return total / float64(pairs)
+}
+
+// jsonKind is the JSON type of a value, without distinguishing integers
+func jsonKind(value interface{}) string {
+	if kind := jsonTypeName(value); kind != "integer" {
+		return kind
+	}
+	return "number"
+}
+
+// canonicalJSON encodes a decoded value with sorted object keys
+func canonicalJSON(value interface{}) string {
+	if text, ok := value.(string); ok {
+		value = strings.TrimSpace(text)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
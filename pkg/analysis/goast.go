@@ -0,0 +1,150 @@
+package analysis
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"hash/fnv"
+)
+
+// GoASTMetric compares the Go code blocks of responses by their syntax trees.
+// Identifiers and literal values are normalized away and every subtree is
+// hashed, so solutions that differ only in naming, constants or formatting
+// score as identical. Pairs where neither response has parseable Go code fall
+// back to the lexical similarity of the text.
+type GoASTMetric struct{}
+
+// Name implements SimilarityMetric
+func (GoASTMetric) Name() string {
+	return "go-ast"
+}
+
+// Matrix implements SimilarityMetric
+func (GoASTMetric) Matrix(responses []string) ([][]float64, error) {
+	fingerprints := make([]map[uint64]int, len(responses))
+	for i, response := range responses {
+		fingerprints[i] = GoASTFingerprint(response)
+	}
+
+	return buildMatrix(len(responses), func(i, j int) float64 {
+		a, b := fingerprints[i], fingerprints[j]
+		switch {
+		case a == nil && b == nil:
+			return OverallSimilarity(responses[i], responses[j])
+		case a == nil || b == nil:
+			return 0
+		}
+		return subtreeSimilarity(a, b)
+	}), nil
+}
+
+// GoASTFingerprint returns the multiset of normalized subtree hashes of the
+// Go code blocks in a response, or nil when none of them parse
+func GoASTFingerprint(response string) map[uint64]int {
+	var fingerprint map[uint64]int
+	for _, block := range ExtractCodeBlocks(response) {
+		if !isGoBlock(block) {
+			continue
+		}
+		source, _, err := parseGoFragment(block.Code)
+		if err != nil {
+			continue
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), "main.go", source, 0)
+		if err != nil {
+			continue
+		}
+		if fingerprint == nil {
+			fingerprint = make(map[uint64]int)
+		}
+		hashSubtrees(file, fingerprint)
+	}
+	return fingerprint
+}
+
+// hashSubtrees adds the hash of every subtree with at least one child to the
+// fingerprint. Leaves are skipped, as after normalization every identifier
+// and literal of a kind is the same leaf.
+func hashSubtrees(root ast.Node, fingerprint map[uint64]int) {
+	type frame struct {
+		label    string
+		children []uint64
+	}
+	var stack []*frame
+
+	ast.Inspect(root, func(n ast.Node) bool {
+		if n == nil {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+
+			h := fnv.New64a()
+			h.Write([]byte(top.label))
+			for _, child := range top.children {
+				fmt.Fprintf(h, "|%x", child)
+			}
+			sum := h.Sum64()
+			if len(top.children) > 0 {
+				fingerprint[sum]++
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, sum)
+			}
+			return true
+		}
+		switch n.(type) {
+		case *ast.CommentGroup, *ast.Comment:
+			return false
+		}
+		stack = append(stack, &frame{label: astNodeLabel(n)})
+		return true
+	})
+}
+
+// astNodeLabel names a node by its type and the operator or keyword that
+// distinguishes otherwise identical shapes, ignoring names and values
+func astNodeLabel(n ast.Node) string {
+	label := fmt.Sprintf("%T", n)
+	switch node := n.(type) {
+	case *ast.BasicLit:
+		label += ":" + node.Kind.String()
+	case *ast.BinaryExpr:
+		label += ":" + node.Op.String()
+	case *ast.UnaryExpr:
+		label += ":" + node.Op.String()
+	case *ast.AssignStmt:
+		label += ":" + node.Tok.String()
+	case *ast.IncDecStmt:
+		label += ":" + node.Tok.String()
+	case *ast.BranchStmt:
+		label += ":" + node.Tok.String()
+	case *ast.GenDecl:
+		label += ":" + node.Tok.String()
+	case *ast.ChanType:
+		label += fmt.Sprintf(":%d", node.Dir)
+	}
+	return label
+}
+
+// subtreeSimilarity is the Dice coefficient of two subtree multisets
+func subtreeSimilarity(a, b map[uint64]int) float64 {
+	shared, total := 0, 0
+	for hash, count := range a {
+		total += count
+		if other, ok := b[hash]; ok {
+			if other < count {
+				shared += other
+			} else {
+				shared += count
+			}
+		}
+	}
+	for _, count := range b {
+		total += count
+	}
+	if total == 0 {
+		return 1.0
+	}
+	return 2 * float64(shared) / float64(total)
+}
@@ -0,0 +1,338 @@
+package analysis
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"sort"
+)
+
+// htmlReport is the view model rendered by the HTML template
+type htmlReport struct {
+	Report  *Report
+	Agents  []htmlAgent
+	Latency []htmlBin
+}
+
+// htmlAgent is one agent's section of the HTML report
+type htmlAgent struct {
+	Name     string
+	ID       string
+	Report   *AgentReport
+	Header   []int // loops in heatmap order
+	Rows     []htmlRow
+	Clusters []htmlCluster
+	Outliers []htmlOutlier
+}
+
+type htmlRow struct {
+	Loop  int
+	Cells []htmlCell
+}
+
+type htmlCell struct {
+	RowLoop int
+	ColLoop int
+	Value   float64
+	Color   template.CSS
+}
+
+type htmlCluster struct {
+	Number     int
+	Size       int
+	Share      float64
+	Silhouette float64
+	MedoidLoop int
+	Members    []htmlResponse
+}
+
+type htmlResponse struct {
+	Loop int
+	Text string
+}
+
+type htmlOutlier struct {
+	Loop           int
+	Score          float64
+	MeanSimilarity float64
+	Text           string
+	MedoidLoop     int
+	MedoidText     string
+	Diff           *ReportDiff
+}
+
+type htmlBin struct {
+	Label   string
+	Count   int
+	Percent float64 // bar width relative to the fullest bin
+}
+
+// WriteHTMLReport renders a report as a single self-contained HTML page with
+// a similarity heatmap, cluster panels, latency histogram and outlier list
+func WriteHTMLReport(w io.Writer, report *Report) error {
+	view := htmlReport{
+		Report:  report,
+		Latency: latencyHistogram(report.Entries, 10),
+	}
+	if report.MainAgent != nil {
+		view.Agents = append(view.Agents, buildHTMLAgent("Main Agent", "main", report.MainAgent))
+	}
+	if report.SubAgent != nil {
+		view.Agents = append(view.Agents, buildHTMLAgent("Sub Agent", "sub", report.SubAgent))
+	}
+
+	return htmlReportTemplate.Execute(w, view)
+}
+
+// buildHTMLAgent reorders the similarity matrix by cluster and resolves loops
+func buildHTMLAgent(name, id string, agent *AgentReport) htmlAgent {
+	view := htmlAgent{Name: name, ID: id, Report: agent}
+
+	indexOfLoop := make(map[int]int, len(agent.ResponseLoops))
+	for i, loop := range agent.ResponseLoops {
+		if _, seen := indexOfLoop[loop]; !seen {
+			indexOfLoop[loop] = i
+		}
+	}
+	text := func(loop int) string {
+		if i, ok := indexOfLoop[loop]; ok && i < len(agent.Responses) {
+			return agent.Responses[i]
+		}
+		return ""
+	}
+
+	// Order responses by cluster, medoid first, so clusters form blocks
+	var order []int
+	clusterOfLoop := make(map[int]int)
+	for c, cluster := range agent.Clusters {
+		members := append([]int(nil), cluster.MemberLoops...)
+		sort.SliceStable(members, func(a, b int) bool {
+			return members[a] == cluster.MedoidLoop && members[b] != cluster.MedoidLoop
+		})
+
+		panel := htmlCluster{
+			Number:     c + 1,
+			Size:       cluster.Size,
+			Share:      cluster.Share,
+			Silhouette: cluster.Silhouette,
+			MedoidLoop: cluster.MedoidLoop,
+		}
+		for _, loop := range members {
+			clusterOfLoop[loop] = c
+			if i, ok := indexOfLoop[loop]; ok {
+				order = append(order, i)
+			}
+			panel.Members = append(panel.Members, htmlResponse{Loop: loop, Text: text(loop)})
+		}
+		view.Clusters = append(view.Clusters, panel)
+	}
+
+	for _, i := range order {
+		view.Header = append(view.Header, agent.ResponseLoops[i])
+	}
+	for _, i := range order {
+		row := htmlRow{Loop: agent.ResponseLoops[i]}
+		for _, j := range order {
+			value := agent.SimilarityMatrix[i][j]
+			row.Cells = append(row.Cells, htmlCell{
+				RowLoop: agent.ResponseLoops[i],
+				ColLoop: agent.ResponseLoops[j],
+				Value:   value,
+				Color:   heatColor(value),
+			})
+		}
+		view.Rows = append(view.Rows, row)
+	}
+
+	for _, outlier := range agent.Outliers {
+		medoidLoop := 0
+		if outlier.Diff != nil {
+			medoidLoop = outlier.Diff.ReferenceLoop
+		} else if c, ok := clusterOfLoop[outlier.Loop]; ok && agent.Clusters[c].MedoidLoop != outlier.Loop {
+			medoidLoop = agent.Clusters[c].MedoidLoop
+		} else if len(agent.Clusters) > 0 {
+			medoidLoop = agent.Clusters[0].MedoidLoop
+		}
+		view.Outliers = append(view.Outliers, htmlOutlier{
+			Loop:           outlier.Loop,
+			Score:          outlier.Score,
+			MeanSimilarity: outlier.MeanSimilarity,
+			Text:           outlier.Response,
+			MedoidLoop:     medoidLoop,
+			MedoidText:     text(medoidLoop),
+			Diff:           outlier.Diff,
+		})
+	}
+
+	return view
+}
+
+// heatColor maps a similarity in [0, 1] to a red-to-green background
+func heatColor(value float64) template.CSS {
+	value = math.Max(0, math.Min(1, value))
+	return template.CSS(fmt.Sprintf("hsl(%.0f, 65%%, %.0f%%)", value*120, 88-value*30))
+}
+
+// latencyHistogram buckets execution times into equal-width bins
+func latencyHistogram(entries []ReportEntry, bins int) []htmlBin {
+	var seconds []float64
+	for _, entry := range entries {
+		if entry.ExecutionSeconds > 0 {
+			seconds = append(seconds, entry.ExecutionSeconds)
+		}
+	}
+	if len(seconds) == 0 {
+		return nil
+	}
+
+	low, high := seconds[0], seconds[0]
+	for _, s := range seconds {
+		low = math.Min(low, s)
+		high = math.Max(high, s)
+	}
+	if high == low {
+		return []htmlBin{{Label: fmt.Sprintf("%.1fs", low), Count: len(seconds), Percent: 100}}
+	}
+	if len(seconds) < bins {
+		bins = len(seconds)
+	}
+
+	width := (high - low) / float64(bins)
+	counts := make([]int, bins)
+	for _, s := range seconds {
+		bin := int((s - low) / width)
+		if bin >= bins {
+			bin = bins - 1
+		}
+		counts[bin]++
+	}
+
+	fullest := 0
+	for _, count := range counts {
+		if count > fullest {
+			fullest = count
+		}
+	}
+
+	histogram := make([]htmlBin, bins)
+	for i, count := range counts {
+		histogram[i] = htmlBin{
+			Label:   fmt.Sprintf("%.1f-%.1fs", low+float64(i)*width, low+float64(i+1)*width),
+			Count:   count,
+			Percent: float64(count) / float64(fullest) * 100,
+		}
+	}
+	return histogram
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"pct":    func(v float64) string { return fmt.Sprintf("%.1f%%", v*100) },
+	"f3":     func(v float64) string { return fmt.Sprintf("%.3f", v) },
+	"mul100": func(v float64) float64 { return v * 100 },
+	"bar":    func(v float64) template.CSS { return template.CSS(fmt.Sprintf("width: %.1f%%", v)) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Agent Reliability Report - {{.Report.Source}}</title>
+<style>
+body { font-family: -apple-system, Segoe UI, Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+h1, h2, h3 { font-weight: 600; }
+table.summary td { padding: 2px 12px 2px 0; }
+.heatmap { border-collapse: collapse; font-size: 10px; }
+.heatmap th { padding: 2px 4px; position: sticky; top: 0; background: #fff; }
+.heatmap td { width: 22px; height: 22px; text-align: center; padding: 0; }
+.heatmap td a { display: block; width: 100%; height: 100%; color: transparent; text-decoration: none; }
+.heatmap td a:hover { color: #000; outline: 1px solid #000; }
+.scroll { overflow: auto; max-height: 80vh; }
+details { margin: 0.5em 0; border: 1px solid #ddd; border-radius: 4px; padding: 0.5em; }
+summary { cursor: pointer; font-weight: 600; }
+pre { white-space: pre-wrap; word-break: break-word; background: #f6f8fa; padding: 0.5em; border-radius: 4px; margin: 0.3em 0; }
+.diff .delete { background: #ffd7d5; color: #82071e; text-decoration: line-through; }
+.diff .insert { background: #ccffd8; color: #055d20; }
+.side { display: grid; grid-template-columns: 1fr 1fr; gap: 1em; }
+.hist td { padding: 1px 6px; }
+.hist .bar { background: #4a90d9; height: 14px; }
+.loop { border-top: 1px solid #ddd; padding-top: 0.5em; }
+.muted { color: #777; }
+</style>
+</head>
+<body>
+<h1>Agent Reliability Report</h1>
+<table class="summary">
+<tr><td>Source</td><td>{{.Report.Source}}</td></tr>
+<tr><td>Generated</td><td>{{.Report.GeneratedAt.Format "2006-01-02 15:04:05 UTC"}}</td></tr>
+<tr><td>Log entries</td><td>{{.Report.TotalEntries}}</td></tr>
+<tr><td>Schema version</td><td>{{.Report.SchemaVersion}}</td></tr>
+</table>
+
+{{range .Agents}}
+<h2 id="{{.ID}}">{{.Name}}</h2>
+<table class="summary">
+{{with .Report.Reliability}}<tr><td>Reliability</td><td><strong>{{.Grade}}</strong> {{.Description}} (score {{f3 .Composite}})</td></tr>
+<tr><td>Limiting metric</td><td>{{.Explanation}}</td></tr>{{end}}
+<tr><td>Responses</td><td>{{.Report.TotalResponses}}</td></tr>
+<tr><td>Average similarity</td><td>{{f3 .Report.AverageSimilarity}}</td></tr>
+<tr><td>Clusters</td><td>{{len .Report.Clusters}} ({{.Report.ClusterMethod}}, silhouette {{f3 .Report.Silhouette}})</td></tr>
+{{with .Report.Assertions}}<tr><td>Assertion pass rate</td><td>{{pct .PassRate}} ({{.LoopsPassed}}/{{.LoopsEvaluated}} loops)</td></tr>{{end}}
+</table>
+
+<h3>Similarity Heatmap</h3>
+<p class="muted">Rows and columns are ordered by cluster, medoid first. Click a cell to jump to the row's loop.</p>
+<div class="scroll">
+<table class="heatmap">
+<tr><th></th>{{range .Header}}<th><a href="#loop-{{.}}">{{.}}</a></th>{{end}}</tr>
+{{range .Rows}}<tr><th><a href="#loop-{{.Loop}}">{{.Loop}}</a></th>{{range .Cells}}<td style="background: {{.Color}}"><a href="#loop-{{.RowLoop}}" title="loop {{.RowLoop}} vs loop {{.ColLoop}}: {{f3 .Value}}">{{printf "%.0f" (mul100 .Value)}}</a></td>{{end}}</tr>
+{{end}}</table>
+</div>
+
+<h3>Clusters</h3>
+{{range .Clusters}}
+<details{{if eq .Number 1}} open{{end}}>
+<summary>Cluster {{.Number}}: {{.Size}} responses ({{pct .Share}}), silhouette {{f3 .Silhouette}}, medoid loop {{.MedoidLoop}}</summary>
+{{range .Members}}<div><a href="#loop-{{.Loop}}">Loop {{.Loop}}</a><pre>{{.Text}}</pre></div>
+{{end}}</details>
+{{end}}
+
+<h3>Outliers</h3>
+{{if .Outliers}}{{range .Outliers}}
+<details open>
+<summary><a href="#loop-{{.Loop}}">Loop {{.Loop}}</a>: score {{f3 .Score}}, mean similarity {{f3 .MeanSimilarity}}</summary>
+<div class="side">
+<div><strong>Loop {{.Loop}}</strong><pre>{{.Text}}</pre></div>
+<div><strong>Medoid (loop {{.MedoidLoop}})</strong><pre>{{.MedoidText}}</pre></div>
+</div>
+{{with .Diff}}<div><strong>Diff against medoid ({{.Mode}} level, {{.Deleted}} removed, {{.Inserted}} added)</strong>
+<pre class="diff">{{range .Segments}}<span class="{{.Op}}">{{.Text}}</span>{{end}}</pre></div>{{end}}
+</details>
+{{end}}{{else}}<p>No outliers flagged ({{.Report.OutlierMethod}}, threshold {{f3 .Report.OutlierThreshold}}).</p>{{end}}
+{{end}}
+
+<h2>Latency</h2>
+{{with .Report.Latency}}<p>{{.Samples}} timed loops: min {{f3 .Min}}s, median {{f3 .Median}}s, p90 {{f3 .P90}}s, p99 {{f3 .P99}}s, max {{f3 .Max}}s (<a href="#loop-{{.SlowestLoop}}">loop {{.SlowestLoop}}</a>), coefficient of variation {{f3 .CV}}</p>{{end}}
+{{if .Latency}}<table class="hist">
+{{range .Latency}}<tr><td>{{.Label}}</td><td style="width: 400px"><div class="bar" style="{{bar .Percent}}"></div></td><td>{{.Count}}</td></tr>
+{{end}}</table>{{else}}<p>No execution times recorded.</p>{{end}}
+
+{{with .Report.Errors}}<h2>Errors</h2>
+<p>{{.LoopsWithErrors}} loops with errors ({{pct .ErrorRate}}){{if .EmptyResponseLoops}}, empty responses in loops {{range .EmptyResponseLoops}}<a href="#loop-{{.}}">{{.}}</a> {{end}}{{end}}</p>
+{{if .Classes}}<table>
+<tr><th>Class</th><th>Loops</th><th>Messages</th><th>Example</th></tr>
+{{range .Classes}}<tr><td>{{.Class}}</td><td>{{range .Loops}}<a href="#loop-{{.}}">{{.}}</a> {{end}}</td><td>{{.Messages}}</td><td>{{.Example}}</td></tr>
+{{end}}</table>{{end}}
+{{end}}
+<h2>Loops</h2>
+{{range .Report.Entries}}
+<div class="loop" id="loop-{{.Loop}}">
+<h3>Loop {{.Loop}} <span class="muted">{{.Timestamp.Format "2006-01-02 15:04:05 UTC"}}, {{printf "%.2f" .ExecutionSeconds}}s</span></h3>
+<details><summary>Prompt</summary><pre>{{.Prompt}}</pre></details>
+<div><strong>Main agent</strong><pre>{{.MainAgentResponse}}</pre></div>
+<div><strong>Sub agent</strong><pre>{{.SubAgentResponse}}</pre></div>
+<details><summary>Raw response</summary><pre>{{.RawResponse}}</pre></details>
+{{if .Errors}}<div><strong>Errors</strong><pre>{{.Errors}}</pre></div>{{end}}
+</div>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,72 @@
+package analysis
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+var fencedJSONRegex = regexp.MustCompile("(?s)```[ \\t]*(?:json|JSON)?[ \\t]*\\n(.*?)\\n[ \\t]*```")
+
+// ExtractJSON finds a JSON value in a response, either as the whole response,
+// inside a fenced code block, or as the outermost object or array in the text
+func ExtractJSON(text string) (interface{}, bool) {
+	text = strings.TrimSpace(text)
+
+	if value, ok := decodeJSON(text); ok {
+		return value, true
+	}
+
+	for _, matches := range fencedJSONRegex.FindAllStringSubmatch(text, -1) {
+		if value, ok := decodeJSON(matches[1]); ok {
+			return value, true
+		}
+	}
+
+	for _, pair := range [][2]string{{"{", "}"}, {"[", "]"}} {
+		start := strings.Index(text, pair[0])
+		end := strings.LastIndex(text, pair[1])
+		if start >= 0 && end > start {
+			if value, ok := decodeJSON(text[start : end+1]); ok {
+				return value, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// decodeJSON parses a complete JSON document, keeping numbers as float64
+func decodeJSON(text string) (interface{}, bool) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, false
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(text), &value); err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// jsonTypeName returns the JSON schema type name of a decoded value
+func jsonTypeName(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "unknown"
+}
@@ -0,0 +1,342 @@
+package analysis
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultJudgeRubric asks the judge whether two answers mean the same thing
+const DefaultJudgeRubric = `You are grading whether two answers produced by an AI agent for the same task are semantically equivalent: they reach the same conclusions and contain the same essential information, even if they are worded, ordered or formatted differently. Ignore differences in tone and length that do not change the substance.
+
+Respond with only a JSON object of the form:
+{"equivalent": true or false, "score": a number from 0 (unrelated) to 1 (identical meaning), "reason": "one sentence"}`
+
+// JudgeVerdict is a judge's structured decision about two answers
+type JudgeVerdict struct {
+	Equivalent bool    `json:"equivalent"`
+	Score      float64 `json:"score"`
+	Reason     string  `json:"reason"`
+}
+
+// Judge grades answers by sending a rubric and the answers to an executor
+// command, such as "claude -p", which reads the prompt on stdin and prints a
+// JSON verdict
+type Judge struct {
+	Command  []string
+	Rubric   string
+	CacheDir string // empty disables caching
+	Workers  int    // concurrent judge commands
+	Timeout  time.Duration
+}
+
+// NewJudge returns a judge running the given command with the default rubric
+func NewJudge(command []string) *Judge {
+	return &Judge{
+		Command: command,
+		Rubric:  DefaultJudgeRubric,
+		Workers: 4,
+		Timeout: 5 * time.Minute,
+	}
+}
+
+// DefaultJudgeCacheDir returns the per-user cache directory for judgments
+func DefaultJudgeCacheDir() string {
+	return defaultCacheDir("judgments")
+}
+
+// Compare judges whether two answers are equivalent. The order of the
+// answers does not affect caching.
+func (j *Judge) Compare(a, b string) (JudgeVerdict, error) {
+	if a > b {
+		a, b = b, a
+	}
+	prompt := fmt.Sprintf("%s\n\nAnswer A:\n<<<\n%s\n>>>\n\nAnswer B:\n<<<\n%s\n>>>\n", j.Rubric, a, b)
+	return j.judge(prompt)
+}
+
+// CompareToReference judges whether a response is equivalent to a reference
+// answer
+func (j *Judge) CompareToReference(response, reference string) (JudgeVerdict, error) {
+	prompt := fmt.Sprintf("%s\n\nReference answer:\n<<<\n%s\n>>>\n\nCandidate answer:\n<<<\n%s\n>>>\n", j.Rubric, reference, response)
+	return j.judge(prompt)
+}
+
+// judge runs the command on a prompt, consulting the cache first
+func (j *Judge) judge(prompt string) (JudgeVerdict, error) {
+	key := j.cacheKey(prompt)
+	if verdict, ok := j.load(key); ok {
+		return verdict, nil
+	}
+
+	if len(j.Command) == 0 {
+		return JudgeVerdict{}, fmt.Errorf("no judge command configured")
+	}
+	timeout := j.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, j.Command[0], j.Command[1:]...)
+	cmd.Stdin = strings.NewReader(prompt)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return JudgeVerdict{}, fmt.Errorf("judge command failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	verdict, err := ParseJudgeVerdict(stdout.String())
+	if err != nil {
+		return JudgeVerdict{}, err
+	}
+	if err := j.store(key, verdict); err != nil {
+		return JudgeVerdict{}, err
+	}
+	return verdict, nil
+}
+
+// ParseJudgeVerdict extracts a verdict from judge output. It accepts an
+// "equivalent" boolean or a "verdict" string, and a "score"; a missing score
+// is taken from the verdict and a missing verdict from the score.
+func ParseJudgeVerdict(output string) (JudgeVerdict, error) {
+	value, ok := ExtractJSON(output)
+	if !ok {
+		return JudgeVerdict{}, fmt.Errorf("judge output contains no JSON verdict: %q", truncateString(strings.TrimSpace(output), 100))
+	}
+	fields, ok := value.(map[string]interface{})
+	if !ok {
+		return JudgeVerdict{}, fmt.Errorf("judge verdict is a %s, expected an object", jsonTypeName(value))
+	}
+
+	var verdict JudgeVerdict
+	equivalent, hasVerdict := fields["equivalent"].(bool)
+	if text, ok := fields["verdict"].(string); ok && !hasVerdict {
+		switch strings.ToLower(strings.TrimSpace(text)) {
+		case "equivalent", "same", "yes", "pass", "match":
+			equivalent, hasVerdict = true, true
+		case "different", "not equivalent", "no", "fail", "mismatch":
+			equivalent, hasVerdict = false, true
+		}
+	}
+	score, hasScore := fields["score"].(float64)
+	if reason, ok := fields["reason"].(string); ok {
+		verdict.Reason = reason
+	}
+
+	switch {
+	case hasVerdict && hasScore:
+		verdict.Equivalent, verdict.Score = equivalent, score
+	case hasVerdict:
+		verdict.Equivalent = equivalent
+		if equivalent {
+			verdict.Score = 1
+		}
+	case hasScore:
+		verdict.Equivalent, verdict.Score = score >= 0.5, score
+	default:
+		return JudgeVerdict{}, fmt.Errorf("judge verdict has neither \"equivalent\" nor \"score\"")
+	}
+	if verdict.Score < 0 || verdict.Score > 1 {
+		return JudgeVerdict{}, fmt.Errorf("judge score %g is outside 0-1", verdict.Score)
+	}
+	return verdict, nil
+}
+
+// cacheKey identifies a judgment by command, rubric and prompt
+func (j *Judge) cacheKey(prompt string) string {
+	sum := sha256.Sum256([]byte(strings.Join(j.Command, "\x00") + "\x00" + prompt))
+	return hex.EncodeToString(sum[:])
+}
+
+// load reads a cached verdict
+func (j *Judge) load(key string) (JudgeVerdict, bool) {
+	if j.CacheDir == "" {
+		return JudgeVerdict{}, false
+	}
+	data, err := os.ReadFile(filepath.Join(j.CacheDir, key+".json"))
+	if err != nil {
+		return JudgeVerdict{}, false
+	}
+	var verdict JudgeVerdict
+	if err := json.Unmarshal(data, &verdict); err != nil {
+		return JudgeVerdict{}, false
+	}
+	return verdict, true
+}
+
+// store caches a verdict
+func (j *Judge) store(key string, verdict JudgeVerdict) error {
+	if j.CacheDir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(j.CacheDir, 0755); err != nil {
+		return fmt.Errorf("failed to create judgment cache: %w", err)
+	}
+	data, err := json.Marshal(verdict)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(j.CacheDir, key+".json"), data, 0644); err != nil {
+		return fmt.Errorf("failed to write judgment cache: %w", err)
+	}
+	return nil
+}
+
+// JudgeMetric uses judge verdicts as similarities, so clusters group answers
+// the judge considers equivalent. Pairs judged equivalent score 1 whatever
+// their score, so they merge under any cluster threshold; other pairs keep
+// the judge's score.
+type JudgeMetric struct {
+	Judge *Judge
+}
+
+// Name implements SimilarityMetric
+func (JudgeMetric) Name() string {
+	return "judge"
+}
+
+// Matrix implements SimilarityMetric. Identical responses are not sent to
+// the judge and distinct pairs are judged concurrently.
+func (m JudgeMetric) Matrix(responses []string) ([][]float64, error) {
+	// Judge each distinct pair of texts once
+	type pair struct{ a, b string }
+	scores := make(map[pair]float64)
+	var pairs []pair
+	for i := range responses {
+		for k := i + 1; k < len(responses); k++ {
+			a, b := responses[i], responses[k]
+			if a == b {
+				continue
+			}
+			if a > b {
+				a, b = b, a
+			}
+			p := pair{a, b}
+			if _, ok := scores[p]; !ok {
+				scores[p] = 0
+				pairs = append(pairs, p)
+			}
+		}
+	}
+
+	verdicts := make([]JudgeVerdict, len(pairs))
+	err := m.Judge.forEach(len(pairs), func(i int) (err error) {
+		verdicts[i], err = m.Judge.Compare(pairs[i].a, pairs[i].b)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i, p := range pairs {
+		scores[p] = verdicts[i].Score
+		if verdicts[i].Equivalent {
+			scores[p] = 1
+		}
+	}
+
+	return buildMatrix(len(responses), func(i, k int) float64 {
+		a, b := responses[i], responses[k]
+		if a == b {
+			return 1
+		}
+		if a > b {
+			a, b = b, a
+		}
+		return scores[pair{a, b}]
+	}), nil
+}
+
+// JudgeResult is one response's judgment against the reference answer
+type JudgeResult struct {
+	Loop    int
+	Verdict JudgeVerdict
+}
+
+// JudgeSummary is how many of an agent's responses the judge found
+// equivalent to the reference answer
+type JudgeSummary struct {
+	Reference  string
+	Judged     int
+	Equivalent int
+	Rate       float64
+	MeanScore  float64
+	Results    []JudgeResult
+}
+
+// JudgeAgainstReference judges every analyzed response against a reference
+// answer
+func JudgeAgainstReference(judge *Judge, result *AnalysisResult, reference string) (*JudgeSummary, error) {
+	entries := result.ResponseEntries
+	verdicts := make([]JudgeVerdict, len(entries))
+	err := judge.forEach(len(entries), func(i int) (err error) {
+		verdicts[i], err = judge.CompareToReference(entries[i].MainAgentResponse, reference)
+		if err != nil {
+			return fmt.Errorf("loop %d: %w", entries[i].Loop, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &JudgeSummary{Reference: reference, Judged: len(entries)}
+	for i, verdict := range verdicts {
+		summary.Results = append(summary.Results, JudgeResult{Loop: entries[i].Loop, Verdict: verdict})
+		summary.MeanScore += verdict.Score
+		if verdict.Equivalent {
+			summary.Equivalent++
+		}
+	}
+	if summary.Judged > 0 {
+		summary.Rate = float64(summary.Equivalent) / float64(summary.Judged)
+		summary.MeanScore /= float64(summary.Judged)
+	}
+	return summary, nil
+}
+
+// forEach runs fn for indices 0..n-1 on the judge's workers, returning the
+// first error
+func (j *Judge) forEach(n int, fn func(i int) error) error {
+	workers := j.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+
+	var mu sync.Mutex
+	var firstErr error
+	var wg sync.WaitGroup
+	jobs := make(chan int)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if err := fn(i); err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return firstErr
+}
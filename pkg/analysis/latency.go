@@ -0,0 +1,140 @@
+package analysis
+
+import "sort"
+
+// LatencyStats summarises the distribution of execution times in seconds
+type LatencyStats struct {
+	Samples     int
+	Min         float64
+	Median      float64
+	P90         float64
+	P99         float64
+	Max         float64
+	Mean        float64
+	StdDev      float64
+	CV          float64 // standard deviation divided by the mean
+	SlowestLoop int
+}
+
+// ClusterLatency is the latency of the responses in one cluster
+type ClusterLatency struct {
+	Cluster int // 1-based, in the order clusters are reported
+	Samples int
+	Median  float64
+	Mean    float64
+}
+
+// LatencyCorrelation relates an agent's execution times to its responses
+type LatencyCorrelation struct {
+	Samples        int
+	LengthPearson  float64 // linear correlation of latency with response length
+	LengthSpearman float64 // rank correlation of latency with response length
+	ClusterEffect  float64 // share of latency variance explained by cluster (eta squared)
+	Clusters       []ClusterLatency
+}
+
+// ComputeLatencyStats summarises the execution times of the entries, returning
+// nil when none were recorded
+func ComputeLatencyStats(entries []LogEntry) *LatencyStats {
+	var seconds []float64
+	stats := &LatencyStats{}
+	slowest := -1.0
+	for _, entry := range entries {
+		if entry.ExecutionTime <= 0 {
+			continue
+		}
+		s := entry.ExecutionTime.Seconds()
+		seconds = append(seconds, s)
+		if s > slowest {
+			slowest = s
+			stats.SlowestLoop = entry.Loop
+		}
+	}
+	if len(seconds) == 0 {
+		return nil
+	}
+
+	sort.Float64s(seconds)
+	stats.Samples = len(seconds)
+	stats.Min = seconds[0]
+	stats.Max = seconds[len(seconds)-1]
+	stats.Median = quantile(seconds, 0.5)
+	stats.P90 = quantile(seconds, 0.9)
+	stats.P99 = quantile(seconds, 0.99)
+
+	stats.Mean, stats.StdDev = meanStdDev(seconds)
+	if stats.Mean > 0 {
+		stats.CV = stats.StdDev / stats.Mean
+	}
+
+	return stats
+}
+
+// CorrelateLatency relates latency to response length and cluster membership,
+// returning nil when fewer than three responses have execution times
+func CorrelateLatency(result *AnalysisResult) *LatencyCorrelation {
+	clusterOf := make(map[int]int)
+	for c, cluster := range result.Clusters {
+		for _, member := range cluster.Responses {
+			clusterOf[member] = c
+		}
+	}
+
+	var latencies, lengths []float64
+	byCluster := make([][]float64, len(result.Clusters))
+	for i, entry := range result.ResponseEntries {
+		if entry.ExecutionTime <= 0 {
+			continue
+		}
+		s := entry.ExecutionTime.Seconds()
+		latencies = append(latencies, s)
+		lengths = append(lengths, float64(len(entry.MainAgentResponse)))
+		if c, ok := clusterOf[i]; ok {
+			byCluster[c] = append(byCluster[c], s)
+		}
+	}
+	if len(latencies) < 3 {
+		return nil
+	}
+
+	correlation := &LatencyCorrelation{
+		Samples:        len(latencies),
+		LengthPearson:  PearsonCorrelation(lengths, latencies),
+		LengthSpearman: SpearmanCorrelation(lengths, latencies),
+	}
+
+	// Eta squared: between-cluster variance over total variance
+	grandMean := 0.0
+	for _, s := range latencies {
+		grandMean += s
+	}
+	grandMean /= float64(len(latencies))
+
+	total, between := 0.0, 0.0
+	for _, s := range latencies {
+		total += (s - grandMean) * (s - grandMean)
+	}
+	for c, values := range byCluster {
+		if len(values) == 0 {
+			continue
+		}
+		mean := 0.0
+		for _, s := range values {
+			mean += s
+		}
+		mean /= float64(len(values))
+		between += float64(len(values)) * (mean - grandMean) * (mean - grandMean)
+
+		correlation.Clusters = append(correlation.Clusters, ClusterLatency{
+			Cluster: c + 1,
+			Samples: len(values),
+			Median:  median(values),
+			Mean:    mean,
+		})
+	}
+	if total > 0 {
+		correlation.ClusterEffect = between / total
+	}
+
+	return correlation
+}
@@ -0,0 +1,88 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+)
+
+// SimilarityMetric computes pairwise similarities in [0, 1] between responses
+type SimilarityMetric interface {
+	Name() string
+	Matrix(responses []string) ([][]float64, error)
+}
+
+// LexicalMetric compares responses by edit distance and word overlap
+type LexicalMetric struct{}
+
+// Name implements SimilarityMetric
+func (LexicalMetric) Name() string {
+	return "lexical"
+}
+
+// Matrix implements SimilarityMetric
+func (LexicalMetric) Matrix(responses []string) ([][]float64, error) {
+	return CalculateSimilarityMatrix(responses), nil
+}
+
+// EmbeddingMetric compares responses by the cosine similarity of their
+// embeddings, so paraphrases score as similar
+type EmbeddingMetric struct {
+	Embedder Embedder
+}
+
+// Name implements SimilarityMetric
+func (EmbeddingMetric) Name() string {
+	return "embedding"
+}
+
+// Matrix implements SimilarityMetric
+func (m EmbeddingMetric) Matrix(responses []string) ([][]float64, error) {
+	vectors, err := m.Embedder.Embed(responses)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed responses: %w", err)
+	}
+	if len(vectors) != len(responses) {
+		return nil, fmt.Errorf("embedder returned %d vectors for %d responses", len(vectors), len(responses))
+	}
+
+	return buildMatrix(len(responses), func(i, j int) float64 {
+		// Opposite directions are as dissimilar as unrelated ones
+		return math.Max(0, CosineSimilarity(vectors[i], vectors[j]))
+	}), nil
+}
+
+// CosineSimilarity returns the cosine of the angle between two vectors, or 0
+// if either is zero or their dimensions differ
+func CosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	dot, normA, normB := 0.0, 0.0, 0.0
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
+
+// buildMatrix fills a symmetric n x n similarity matrix with ones on the
+// diagonal
+func buildMatrix(n int, similarity func(i, j int) float64) [][]float64 {
+	matrix := make([][]float64, n)
+	for i := range matrix {
+		matrix[i] = make([]float64, n)
+		matrix[i][i] = 1.0
+	}
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			matrix[i][j] = similarity(i, j)
+			matrix[j][i] = matrix[i][j]
+		}
+	}
+	return matrix
+}
@@ -0,0 +1,281 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// OutlierMethod selects the statistic used to score anomalous responses
+type OutlierMethod string
+
+const (
+	OutlierMAD OutlierMethod = "mad" // modified z-score of mean similarity (median/MAD)
+	OutlierLOF OutlierMethod = "lof" // local outlier factor over the distance matrix
+)
+
+// Default thresholds for each outlier method
+const (
+	DefaultMADThreshold = 3.5
+	DefaultLOFThreshold = 1.5
+)
+
+// ParseOutlierMethod validates an outlier method name
+func ParseOutlierMethod(name string) (OutlierMethod, error) {
+	switch OutlierMethod(strings.ToLower(name)) {
+	case OutlierMAD:
+		return OutlierMAD, nil
+	case OutlierLOF:
+		return OutlierLOF, nil
+	}
+	return "", fmt.Errorf("unknown outlier method %q (expected one of: mad, lof)", name)
+}
+
+// OutlierOptions configures outlier detection
+type OutlierOptions struct {
+	Method     OutlierMethod
+	Threshold  float64 // scores above this are flagged, 0 uses the method default
+	Neighbours int     // LOF: neighbourhood size, 0 uses min(5, n-1)
+}
+
+// DefaultOutlierOptions returns the options used by the analyzer
+func DefaultOutlierOptions() OutlierOptions {
+	return OutlierOptions{
+		Method: OutlierMAD,
+	}
+}
+
+// EffectiveThreshold returns the configured threshold or the method default
+func (o OutlierOptions) EffectiveThreshold() float64 {
+	if o.Threshold > 0 {
+		return o.Threshold
+	}
+	if o.Method == OutlierLOF {
+		return DefaultLOFThreshold
+	}
+	return DefaultMADThreshold
+}
+
+// DetectOutliers scores every response and returns the ones above the
+// threshold, most anomalous first, with their nearest normal neighbour
+func DetectOutliers(entries []LogEntry, matrix [][]float64, opts OutlierOptions) ([]AnomalyScore, []Outlier) {
+	n := len(entries)
+	if n < 3 {
+		// Too few responses to say what is typical
+		scores := make([]AnomalyScore, n)
+		for i := range scores {
+			scores[i] = AnomalyScore{Index: i, Loop: entries[i].Loop, MeanSimilarity: meanSimilarity(matrix, i)}
+		}
+		return scores, nil
+	}
+
+	var raw []float64
+	if opts.Method == OutlierLOF {
+		raw = LocalOutlierFactors(matrix, opts.Neighbours)
+	} else {
+		raw = RobustZScores(meanSimilarities(matrix))
+	}
+
+	threshold := opts.EffectiveThreshold()
+	scores := make([]AnomalyScore, n)
+	for i := range scores {
+		scores[i] = AnomalyScore{
+			Index:          i,
+			Loop:           entries[i].Loop,
+			MeanSimilarity: meanSimilarity(matrix, i),
+			Score:          raw[i],
+			Outlier:        raw[i] > threshold,
+		}
+	}
+
+	var outliers []Outlier
+	for _, score := range scores {
+		if !score.Outlier {
+			continue
+		}
+
+		outlier := Outlier{
+			Entry:          entries[score.Index],
+			Index:          score.Index,
+			Score:          score.Score,
+			MeanSimilarity: score.MeanSimilarity,
+			NearestNormal:  -1,
+		}
+		for j, other := range scores {
+			if other.Outlier {
+				continue
+			}
+			if outlier.NearestNormal == -1 || matrix[score.Index][j] > outlier.NearestNormalSimilarity {
+				outlier.NearestNormal = j
+				outlier.NearestNormalLoop = entries[j].Loop
+				outlier.NearestNormalSimilarity = matrix[score.Index][j]
+			}
+		}
+		outliers = append(outliers, outlier)
+	}
+
+	sort.SliceStable(outliers, func(i, j int) bool {
+		return outliers[i].Score > outliers[j].Score
+	})
+
+	return scores, outliers
+}
+
+// RobustZScores computes modified z-scores (Iglewicz and Hoaglin) of how far
+// each value falls below the median. Values below the median score positive.
+func RobustZScores(values []float64) []float64 {
+	scores := make([]float64, len(values))
+	if len(values) == 0 {
+		return scores
+	}
+
+	med := median(values)
+	deviations := make([]float64, len(values))
+	meanDeviation := 0.0
+	for i, v := range values {
+		deviations[i] = math.Abs(v - med)
+		meanDeviation += deviations[i]
+	}
+	meanDeviation /= float64(len(values))
+
+	// Fall back to the mean absolute deviation when more than half the
+	// values are identical and the MAD collapses to zero
+	scale := median(deviations) / 0.6745
+	if scale == 0 {
+		scale = 1.253314 * meanDeviation
+	}
+	if scale == 0 {
+		return scores
+	}
+
+	for i, v := range values {
+		scores[i] = (med - v) / scale
+	}
+	return scores
+}
+
+// LocalOutlierFactors computes the local outlier factor of every response
+// using distance 1 - similarity. Scores near 1 are typical, larger scores
+// mean the response sits in a sparser region than its neighbours. Identical
+// responses are collapsed into one point so duplicates do not produce
+// infinite densities.
+func LocalOutlierFactors(matrix [][]float64, k int) []float64 {
+	n := len(matrix)
+	factors := make([]float64, n)
+	for i := range factors {
+		factors[i] = 1.0
+	}
+
+	// Map every response to the first identical response
+	var points []int
+	pointOf := make([]int, n)
+	for i := 0; i < n; i++ {
+		pointOf[i] = -1
+		for p, rep := range points {
+			if matrix[i][rep] >= 1.0-1e-12 {
+				pointOf[i] = p
+				break
+			}
+		}
+		if pointOf[i] == -1 {
+			pointOf[i] = len(points)
+			points = append(points, i)
+		}
+	}
+
+	m := len(points)
+	if m < 2 {
+		return factors
+	}
+	if k <= 0 {
+		k = 5
+	}
+	if k > m-1 {
+		k = m - 1
+	}
+
+	distance := func(a, b int) float64 {
+		return 1.0 - matrix[points[a]][points[b]]
+	}
+
+	// Neighbourhoods include every point tied with the k-th nearest
+	neighbours := make([][]int, m)
+	kDistance := make([]float64, m)
+	for a := 0; a < m; a++ {
+		others := make([]int, 0, m-1)
+		for b := 0; b < m; b++ {
+			if b != a {
+				others = append(others, b)
+			}
+		}
+		sort.SliceStable(others, func(x, y int) bool {
+			return distance(a, others[x]) < distance(a, others[y])
+		})
+
+		kDistance[a] = distance(a, others[k-1])
+		for _, b := range others {
+			if distance(a, b) <= kDistance[a] {
+				neighbours[a] = append(neighbours[a], b)
+			}
+		}
+	}
+
+	// Local reachability density of each point
+	const minReach = 1e-6
+	density := make([]float64, m)
+	for a := 0; a < m; a++ {
+		total := 0.0
+		for _, b := range neighbours[a] {
+			total += math.Max(kDistance[b], distance(a, b))
+		}
+		density[a] = 1.0 / math.Max(total/float64(len(neighbours[a])), minReach)
+	}
+
+	for i := 0; i < n; i++ {
+		a := pointOf[i]
+		total := 0.0
+		for _, b := range neighbours[a] {
+			total += density[b]
+		}
+		factors[i] = total / float64(len(neighbours[a])) / density[a]
+	}
+	return factors
+}
+
+// meanSimilarities returns each response's mean similarity to the others
+func meanSimilarities(matrix [][]float64) []float64 {
+	means := make([]float64, len(matrix))
+	for i := range matrix {
+		means[i] = meanSimilarity(matrix, i)
+	}
+	return means
+}
+
+// meanSimilarity returns the mean similarity of response i to the others
+func meanSimilarity(matrix [][]float64, i int) float64 {
+	if len(matrix) < 2 {
+		return 1.0
+	}
+	total := 0.0
+	for j := range matrix {
+		if j != i {
+			total += matrix[i][j]
+		}
+	}
+	return total / float64(len(matrix)-1)
+}
+
+// median returns the median of values without modifying them
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0.0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
@@ -0,0 +1,102 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRobustZScores(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		want   []float64
+	}{
+		{
+			// Median 3, MAD 1: scores are (3 - v) * 0.6745
+			name:   "median absolute deviation",
+			values: []float64{1, 2, 3, 4, 100},
+			want:   []float64{1.349, 0.6745, 0, -0.6745, -65.4265},
+		},
+		{
+			// MAD is 0, so the scale is 1.253314 * mean deviation (0.8)
+			name:   "collapsed MAD",
+			values: []float64{5, 5, 5, 5, 1},
+			want:   []float64{0, 0, 0, 0, 4 / (1.253314 * 0.8)},
+		},
+		{
+			name:   "identical values",
+			values: []float64{0.7, 0.7, 0.7},
+			want:   []float64{0, 0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RobustZScores(tt.values)
+			for i := range tt.want {
+				if math.Abs(got[i]-tt.want[i]) > 1e-9 {
+					t.Errorf("score %d = %.6f, want %.6f", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLocalOutlierFactors(t *testing.T) {
+	// Points 0, 1, 2 and 6 with k = 2. The k-distances are 2, 1, 2 and 5,
+	// the mean reachability distances 1.5, 2, 1.5 and 4.5, so the local
+	// reachability densities are 2/3, 1/2, 2/3 and 2/9. Each factor is the
+	// mean density of the neighbours over the point's own.
+	tests := []struct {
+		name string
+		xs   []float64
+		want []float64
+	}{
+		{
+			name: "distinct points",
+			xs:   []float64{0, 1, 2, 6},
+			want: []float64{0.875, 4.0 / 3, 0.875, 2.625},
+		},
+		{
+			name: "duplicates collapse into one point",
+			xs:   []float64{0, 0, 1, 2, 6},
+			want: []float64{0.875, 0.875, 4.0 / 3, 0.875, 2.625},
+		},
+		{
+			name: "all identical",
+			xs:   []float64{3, 3, 3},
+			want: []float64{1, 1, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := LocalOutlierFactors(lineMatrix(tt.xs, 10), 2)
+			for i := range tt.want {
+				if math.Abs(got[i]-tt.want[i]) > 1e-9 {
+					t.Errorf("factor %d = %.6f, want %.6f", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDetectOutliersLOF(t *testing.T) {
+	matrix := lineMatrix([]float64{0, 1, 2, 6}, 10)
+	entries := []LogEntry{{Loop: 1}, {Loop: 2}, {Loop: 3}, {Loop: 4}}
+
+	scores, outliers := DetectOutliers(entries, matrix, OutlierOptions{Method: OutlierLOF, Threshold: 2, Neighbours: 2})
+	if len(scores) != 4 {
+		t.Fatalf("got %d scores, want 4", len(scores))
+	}
+	if len(outliers) != 1 {
+		t.Fatalf("got %d outliers, want 1", len(outliers))
+	}
+	outlier := outliers[0]
+	if outlier.Entry.Loop != 4 || outlier.NearestNormalLoop != 3 {
+		t.Errorf("outlier loop %d nearest %d, want loop 4 nearest 3", outlier.Entry.Loop, outlier.NearestNormalLoop)
+	}
+	if math.Abs(outlier.Score-2.625) > 1e-9 {
+		t.Errorf("outlier score = %.6f, want 2.625", outlier.Score)
+	}
+}
@@ -2,7 +2,11 @@ package analysis
 
 import (
 	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"regexp"
 	"strconv"
@@ -10,99 +14,405 @@ import (
 	"time"
 )
 
-func ParseLogFile(filename string) ([]LogEntry, error) {
+// Log line patterns
+var (
+	headerRegex        = regexp.MustCompile(`^=== Loop (\d+)/\d+ - (.+) ===`)
+	promptRegex        = regexp.MustCompile(`^Prompt: (.+)`)
+	sectionRegex       = regexp.MustCompile(`^(Prompt|Response|Errors|Tools) \((\d+) bytes\):$`)
+	responseStartRegex = regexp.MustCompile(`^Response:`)
+	errorStartRegex    = regexp.MustCompile(`^Errors:`)
+	executionTimeRegex = regexp.MustCompile(`^Execution time: (.+)`)
+	separatorRegex     = regexp.MustCompile(`^---`)
+	metadataRegex      = regexp.MustCompile(`^# (Agent|Template|Loops|Started): (.*)$`)
+)
+
+// Agent response patterns
+var (
+	mainPattern1      = regexp.MustCompile(`\*\*What I told the agent:\*\*\s*"([^"]+)"`)
+	mainPattern2      = regexp.MustCompile(`(?s)\*\*What I told the agent:\*\*\s*\n\s*"([^"]+)"`)
+	agentPattern1     = regexp.MustCompile(`\*\*Agent[^:]*response[^:]*:\*\*\s*"([^"]+)"`)
+	agentPattern2     = regexp.MustCompile(`(?s)\*\*Agent[^:]*response[^:]*:\*\*\s*(.+?)\s*(?:\n\s*\*\*What I told|\z)`)
+	agentPattern3     = regexp.MustCompile(`(?s)\*\*Agent[^:]*response[^:]*:\*\*\s*\n\s*"([^"]+)"`)
+	agentLabelPattern = regexp.MustCompile(`[Aa]gent[^:]*response[^:]*:`)
+)
+
+const logTimeLayout = "2006-01-02 15:04:05 UTC"
+
+// ParseWarning describes a malformed part of a log that was skipped or
+// parsed with a fallback
+type ParseWarning struct {
+	Line    int // 1-based line number
+	Loop    int // loop being parsed, 0 before the first entry
+	Message string
+}
+
+func (w ParseWarning) String() string {
+	if w.Loop > 0 {
+		return fmt.Sprintf("line %d (loop %d): %s", w.Line, w.Loop, w.Message)
+	}
+	return fmt.Sprintf("line %d: %s", w.Line, w.Message)
+}
+
+// LogReader streams entries from a log without holding the whole file in
+// memory. Lines may be of any length and gzip-compressed logs are detected
+// automatically. Use it like bufio.Scanner:
+//
+//	for reader.Next() {
+//		entry := reader.Entry()
+//	}
+//	if err := reader.Err(); err != nil { ... }
+type LogReader struct {
+	reader   *bufio.Reader
+	closers  []io.Closer
+	metadata LogMetadata
+	warnings []ParseWarning
+	line     int
+	pending  *string // line read ahead while looking for the end of an entry
+	entry    LogEntry
+	seen     map[int]bool
+	err      error
+	done     bool
+}
+
+// OpenLog opens a plain or gzip-compressed log file for streaming
+func OpenLog(filename string) (*LogReader, error) {
 	file, err := os.Open(filename)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open log file: %w", err)
 	}
-	defer file.Close()
 
-	var entries []LogEntry
-	var currentEntry LogEntry
-	var inResponse bool
-	var responseBuilder strings.Builder
-
-	scanner := bufio.NewScanner(file)
-	headerRegex := regexp.MustCompile(`^=== Loop (\d+)/\d+ - (.+) ===`)
-	promptRegex := regexp.MustCompile(`^Prompt: (.+)`)
-	responseStartRegex := regexp.MustCompile(`^Response:`)
-	errorStartRegex := regexp.MustCompile(`^Errors:`)
-	executionTimeRegex := regexp.MustCompile(`^Execution time: (.+)`)
-	separatorRegex := regexp.MustCompile(`^---`)
-
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		if matches := headerRegex.FindStringSubmatch(line); matches != nil {
-			// Start of new entry
-			if currentEntry.Loop != 0 {
-				// Save previous entry
-				rawResponse := strings.TrimSpace(responseBuilder.String())
-				currentEntry.RawResponse = rawResponse
-
-				// Extract both agent responses
-				mainResp, subResp := extractBothAgentResponses(rawResponse)
-				currentEntry.MainAgentResponse = mainResp
-				currentEntry.SubAgentResponse = subResp
-
-				entries = append(entries, currentEntry)
-			}
+	reader, err := NewLogReader(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	reader.closers = append(reader.closers, file)
+	return reader, nil
+}
 
-			// Parse loop number
-			loop, _ := strconv.Atoi(matches[1])
+// NewLogReader streams a log from r, decompressing it if it is gzipped. The
+// run header is read immediately and available from Metadata.
+func NewLogReader(r io.Reader) (*LogReader, error) {
+	reader := &LogReader{reader: bufio.NewReader(r), seen: make(map[int]bool)}
 
-			// Parse timestamp
-			timestamp, err := time.Parse("2006-01-02 15:04:05 UTC", matches[2])
-			if err != nil {
-				// Try alternative format
-				timestamp = time.Now() // fallback
+	// Gzip streams start with the magic bytes 1f 8b
+	if magic, err := reader.reader.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(reader.reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gzip log: %w", err)
+		}
+		reader.reader = bufio.NewReader(gz)
+		reader.closers = append(reader.closers, gz)
+	}
+
+	if err := reader.readHeader(); err != nil {
+		return nil, err
+	}
+	return reader, nil
+}
+
+// Metadata returns the run header, empty for logs written without one
+func (r *LogReader) Metadata() LogMetadata {
+	return r.metadata
+}
+
+// Warnings returns the problems found so far
+func (r *LogReader) Warnings() []ParseWarning {
+	return r.warnings
+}
+
+// Entry returns the entry read by the last successful call to Next
+func (r *LogReader) Entry() LogEntry {
+	return r.entry
+}
+
+// Err returns the first read error, if any
+func (r *LogReader) Err() error {
+	return r.err
+}
+
+// Close closes the underlying file and decompressor
+func (r *LogReader) Close() error {
+	var firstErr error
+	for i := len(r.closers) - 1; i >= 0; i-- {
+		if err := r.closers[i].Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	r.closers = nil
+	return firstErr
+}
+
+// readLine returns the next line without its line ending, reading lines of
+// any length
+func (r *LogReader) readLine() (string, bool) {
+	if r.pending != nil {
+		line := *r.pending
+		r.pending = nil
+		return line, true
+	}
+	if r.err != nil {
+		return "", false
+	}
+
+	line, err := r.reader.ReadString('\n')
+	if err != nil {
+		if !errors.Is(err, io.EOF) {
+			r.err = fmt.Errorf("error reading log file: %w", err)
+			return "", false
+		}
+		if line == "" {
+			return "", false
+		}
+	}
+	r.line++
+	return strings.TrimRight(line, "\r\n"), true
+}
+
+// readPayload reads exactly size bytes of section content and the newline
+// that terminates it, reporting whether the content was complete
+func (r *LogReader) readPayload(size int64) (string, bool) {
+	var content bytes.Buffer
+	_, err := io.CopyN(&content, r.reader, size)
+	r.line += bytes.Count(content.Bytes(), []byte("\n"))
+	if err != nil {
+		if !errors.Is(err, io.EOF) {
+			r.err = fmt.Errorf("error reading log file: %w", err)
+		}
+		return content.String(), false
+	}
+
+	// The content is followed by a newline ending its last line
+	if next, err := r.reader.ReadByte(); err == nil {
+		if next == '\n' {
+			r.line++
+		} else {
+			r.reader.UnreadByte()
+		}
+	}
+	return content.String(), true
+}
+
+// unreadLine pushes a line back to be returned by the next readLine
+func (r *LogReader) unreadLine(line string) {
+	r.pending = &line
+}
+
+// warn records a parse warning at the current line
+func (r *LogReader) warn(loop int, format string, args ...interface{}) {
+	r.warnAt(r.line, loop, format, args...)
+}
+
+// warnAt records a parse warning at the given line
+func (r *LogReader) warnAt(line, loop int, format string, args ...interface{}) {
+	r.warnings = append(r.warnings, ParseWarning{Line: line, Loop: loop, Message: fmt.Sprintf(format, args...)})
+}
+
+// readHeader reads the run header up to the first loop
+func (r *LogReader) readHeader() error {
+	for {
+		line, ok := r.readLine()
+		if !ok {
+			return r.err
+		}
+		if headerRegex.MatchString(line) {
+			r.unreadLine(line)
+			return nil
+		}
+
+		matches := metadataRegex.FindStringSubmatch(line)
+		if matches == nil {
+			if strings.TrimSpace(line) != "" {
+				r.warn(0, "unexpected line before the first loop: %q", truncateString(line, 60))
 			}
+			continue
+		}
+		value := strings.TrimSpace(matches[2])
+		switch matches[1] {
+		case "Agent":
+			r.metadata.Agent = value
+		case "Template":
+			r.metadata.Template = value
+		case "Loops":
+			r.metadata.Loops, _ = strconv.Atoi(value)
+		case "Started":
+			r.metadata.Started, _ = time.Parse(logTimeLayout, value)
+		}
+	}
+}
+
+// Next reads the next entry, returning false at the end of the log or on a
+// read error
+func (r *LogReader) Next() bool {
+	if r.done {
+		return false
+	}
+
+	// Find the next loop header
+	var matches []string
+	for matches == nil {
+		line, ok := r.readLine()
+		if !ok {
+			r.done = true
+			return false
+		}
+		if matches = headerRegex.FindStringSubmatch(line); matches == nil && strings.TrimSpace(line) != "" {
+			r.warn(0, "line outside of any loop: %q", truncateString(line, 60))
+		}
+	}
+
+	start := r.line
+	loop, _ := strconv.Atoi(matches[1])
+	timestamp, err := time.Parse(logTimeLayout, matches[2])
+	if err != nil {
+		r.warn(loop, "invalid timestamp %q", matches[2])
+		timestamp = time.Now() // fallback
+	}
+	if r.seen[loop] {
+		r.warn(loop, "duplicate loop number")
+	}
+	r.seen[loop] = true
+
+	entry := LogEntry{Loop: loop, Timestamp: timestamp}
+	var hasResponse, hasTime, ended bool
+	var legacyResponse, legacyErrors []string
+	section := ""
 
-			currentEntry = LogEntry{
-				Loop:      loop,
-				Timestamp: timestamp,
+	for {
+		line, ok := r.readLine()
+		if !ok {
+			break
+		}
+		if headerRegex.MatchString(line) {
+			r.unreadLine(line)
+			break
+		}
+		if ended {
+			// Only the separator is expected between the execution time and
+			// the next loop
+			continue
+		}
+
+		if matches := sectionRegex.FindStringSubmatch(line); matches != nil {
+			// Length-prefixed section: read exactly the declared bytes
+			size, _ := strconv.ParseInt(matches[2], 10, 64)
+			content, complete := r.readPayload(size)
+			if !complete {
+				r.warn(loop, "%s section is shorter than its declared %d bytes", matches[1], size)
+			}
+			switch matches[1] {
+			case "Prompt":
+				entry.Prompt = content
+			case "Response":
+				entry.RawResponse = content
+				hasResponse = true
+			case "Errors":
+				entry.Errors = content
+			case "Tools":
+				calls, err := ParseToolTrace(content)
+				if err != nil {
+					r.warn(loop, "invalid tool trace: %v", err)
+				}
+				entry.ToolCalls, entry.ToolTrace = calls, true
 			}
-			responseBuilder.Reset()
-			inResponse = false
+			section = ""
+		} else if matches := executionTimeRegex.FindStringSubmatch(line); matches != nil {
+			duration, err := time.ParseDuration(matches[1])
+			if err != nil {
+				r.warn(loop, "invalid execution time %q", matches[1])
+			}
+			entry.ExecutionTime = duration
+			hasTime, ended = true, true
+		} else if section == "response" && !errorStartRegex.MatchString(line) {
+			legacyResponse = append(legacyResponse, line)
+		} else if section == "errors" {
+			legacyErrors = append(legacyErrors, line)
 		} else if matches := promptRegex.FindStringSubmatch(line); matches != nil {
-			currentEntry.Prompt = matches[1]
+			entry.Prompt = matches[1]
 		} else if responseStartRegex.MatchString(line) {
-			inResponse = true
-			responseBuilder.Reset()
+			// Older logs delimit the response by the Errors and Execution
+			// time lines, keeping blank lines and rules inside it
+			section, hasResponse = "response", true
 		} else if errorStartRegex.MatchString(line) {
-			inResponse = false
-		} else if matches := executionTimeRegex.FindStringSubmatch(line); matches != nil {
-			duration, _ := time.ParseDuration(matches[1])
-			currentEntry.ExecutionTime = duration
-			inResponse = false
+			section = "errors"
 		} else if separatorRegex.MatchString(line) {
-			inResponse = false
-		} else if inResponse && strings.TrimSpace(line) != "" {
-			if responseBuilder.Len() > 0 {
-				responseBuilder.WriteString("\n")
-			}
-			responseBuilder.WriteString(line)
+			section = ""
 		}
 	}
+	if r.err != nil {
+		return false
+	}
+
+	if !hasResponse {
+		r.warnAt(start, loop, "entry has no Response section")
+	}
+	if !hasTime {
+		r.warnAt(start, loop, "entry has no execution time, it may be truncated")
+	}
+
+	if legacyResponse != nil {
+		entry.RawResponse = strings.TrimSpace(strings.Join(legacyResponse, "\n"))
+	}
+	if legacyErrors != nil {
+		entry.Errors = strings.TrimSpace(strings.Join(legacyErrors, "\n"))
+	}
+	entry.MainAgentResponse, entry.SubAgentResponse = extractBothAgentResponses(entry.RawResponse)
 
-	// Don't forget the last entry
-	if currentEntry.Loop != 0 {
-		rawResponse := strings.TrimSpace(responseBuilder.String())
-		currentEntry.RawResponse = rawResponse
+	r.entry = entry
+	return true
+}
+
+// ForEachLogEntry streams every entry of a log file to fn, stopping early if
+// fn returns an error
+func ForEachLogEntry(filename string, fn func(LogEntry) error) ([]ParseWarning, error) {
+	reader, err := OpenLog(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
 
-		// Extract both agent responses
-		mainResp, subResp := extractBothAgentResponses(rawResponse)
-		currentEntry.MainAgentResponse = mainResp
-		currentEntry.SubAgentResponse = subResp
+	for reader.Next() {
+		if err := fn(reader.Entry()); err != nil {
+			return reader.Warnings(), err
+		}
+	}
+	return reader.Warnings(), reader.Err()
+}
 
-		entries = append(entries, currentEntry)
+// ParseLogFileWithWarnings reads every entry of a log file along with its
+// run header and any parse warnings
+func ParseLogFileWithWarnings(filename string) ([]LogEntry, LogMetadata, []ParseWarning, error) {
+	reader, err := OpenLog(filename)
+	if err != nil {
+		return nil, LogMetadata{}, nil, err
 	}
+	defer reader.Close()
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading log file: %w", err)
+	var entries []LogEntry
+	for reader.Next() {
+		entries = append(entries, reader.Entry())
+	}
+	if err := reader.Err(); err != nil {
+		return nil, LogMetadata{}, nil, err
 	}
+	return entries, reader.Metadata(), reader.Warnings(), nil
+}
 
-	return entries, nil
+// ParseLogFile reads every entry of a log file
+func ParseLogFile(filename string) ([]LogEntry, error) {
+	entries, _, _, err := ParseLogFileWithWarnings(filename)
+	return entries, err
+}
+
+// ParseLogMetadata reads the run header at the top of a log file. Logs
+// written before headers were added return empty metadata.
+func ParseLogMetadata(filename string) (LogMetadata, error) {
+	reader, err := OpenLog(filename)
+	if err != nil {
+		return LogMetadata{}, err
+	}
+	defer reader.Close()
+	return reader.Metadata(), nil
 }
 
 // extractBothAgentResponses extracts both main agent and sub agent responses
@@ -124,13 +434,11 @@ func extractBothAgentResponses(rawResponse string) (mainAgent, subAgent string)
 // extractMainAgentResponse extracts the "What I told the agent" part
 func extractMainAgentResponse(rawResponse string) string {
 	// Pattern 1: **What I told the agent:** "text"
-	mainPattern1 := regexp.MustCompile(`\*\*What I told the agent:\*\*\s*"([^"]+)"`)
 	if matches := mainPattern1.FindStringSubmatch(rawResponse); matches != nil {
 		return strings.TrimSpace(matches[1])
 	}
 
 	// Pattern 2: **What I told the agent:**\n"text"
-	mainPattern2 := regexp.MustCompile(`(?s)\*\*What I told the agent:\*\*\s*\n\s*"([^"]+)"`)
 	if matches := mainPattern2.FindStringSubmatch(rawResponse); matches != nil {
 		return strings.TrimSpace(matches[1])
 	}
@@ -177,13 +485,12 @@ func extractMainAgentResponse(rawResponse string) string {
 // extractSubAgentResponse extracts the "Agent's response" part
 func extractSubAgentResponse(rawResponse string) string {
 	// Pattern 1: **Agent's response:** "response text"
-	agentPattern1 := regexp.MustCompile(`\*\*Agent[^:]*response[^:]*:\*\*\s*"([^"]+)"`)
 	if matches := agentPattern1.FindStringSubmatch(rawResponse); matches != nil {
 		return strings.TrimSpace(matches[1])
 	}
 
-	// Pattern 2: **Agent's response:** (without quotes, multiline)
-	agentPattern2 := regexp.MustCompile(`(?s)\*\*Agent[^:]*response[^:]*:\*\*\s*(.+?)(?:\n\n|$)`)
+	// Pattern 2: **Agent's response:** (without quotes, multiline) up to the
+	// next "What I told" label or the end, keeping blank lines and code blocks
 	if matches := agentPattern2.FindStringSubmatch(rawResponse); matches != nil {
 		response := strings.TrimSpace(matches[1])
 		// Remove quotes if present
@@ -194,46 +501,45 @@ func extractSubAgentResponse(rawResponse string) string {
 	}
 
 	// Pattern 3: **Agent's response:**\n"response text"
-	agentPattern3 := regexp.MustCompile(`(?s)\*\*Agent[^:]*response[^:]*:\*\*\s*\n\s*"([^"]+)"`)
 	if matches := agentPattern3.FindStringSubmatch(rawResponse); matches != nil {
 		return strings.TrimSpace(matches[1])
 	}
 
-	// Pattern 4: Look for text after "Agent response:" or similar (most flexible)
+	// Pattern 4: Look for text after "Agent response:" or similar (most
+	// flexible). Lines are kept as written so code blocks survive.
 	lines := strings.Split(rawResponse, "\n")
 	foundAgentResponse := false
 	var responseLines []string
 
 	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if strings.Contains(strings.ToLower(line), "agent") && strings.Contains(strings.ToLower(line), "response") {
+		trimmed := strings.TrimSpace(line)
+		if !foundAgentResponse {
+			lower := strings.ToLower(trimmed)
+			if !strings.Contains(lower, "agent") || !strings.Contains(lower, "response") {
+				continue
+			}
 			foundAgentResponse = true
 			// Check if response starts on same line
-			parts := regexp.MustCompile(`[Aa]gent[^:]*response[^:]*:`).Split(line, 2)
+			parts := agentLabelPattern.Split(trimmed, 2)
 			if len(parts) > 1 {
-				remaining := strings.TrimSpace(parts[1])
-				if remaining != "" {
-					remaining = strings.Trim(remaining, `"`)
+				if remaining := strings.TrimSpace(parts[1]); remaining != "" {
 					responseLines = append(responseLines, remaining)
 				}
 			}
 			continue
 		}
 
-		if foundAgentResponse && line != "" {
-			// Skip lines that look like metadata
-			if strings.HasPrefix(line, "**What I told") {
-				break
-			}
-			// Remove quotes if this line is wrapped in quotes
-			line = strings.Trim(line, `"`)
-			responseLines = append(responseLines, line)
+		// Stop at the main agent's section
+		if strings.HasPrefix(trimmed, "**What I told") {
+			break
 		}
+		responseLines = append(responseLines, line)
 	}
 
-	if len(responseLines) > 0 {
-		return strings.TrimSpace(strings.Join(responseLines, " "))
+	response := strings.TrimSpace(strings.Join(responseLines, "\n"))
+	// Remove quotes if the whole response is wrapped in them
+	if len(response) > 1 && strings.HasPrefix(response, `"`) && strings.HasSuffix(response, `"`) {
+		response = strings.TrimSpace(response[1 : len(response)-1])
 	}
-
-	return ""
+	return response
 }
@@ -0,0 +1,63 @@
+package analysis
+
+import "testing"
+
+func TestExtractAgentResponses(t *testing.T) {
+	codeResponse := "Here is code:\n\n```go\nfunc add(a, b int) int {\n\n\treturn a + b\n}\n```\n\n---\n\nDone."
+
+	tests := []struct {
+		name string
+		raw  string
+		main string
+		sub  string
+	}{
+		{
+			name: "quoted on one line",
+			raw:  "**What I told the agent:** \"Say hello\"\n\n**Agent's response:** \"Hello!\"",
+			main: "Say hello",
+			sub:  "Hello!",
+		},
+		{
+			name: "blank lines, code block and rule",
+			raw:  "**What I told the agent:** \"Write add\"\n\n**Agent's response:**\n" + codeResponse,
+			main: "Write add",
+			sub:  codeResponse,
+		},
+		{
+			name: "stops at the main agent label",
+			raw:  "**Agent's response:**\nFirst paragraph.\n\nSecond paragraph.\n\n**What I told the agent:** \"Summarize\"",
+			main: "Summarize",
+			sub:  "First paragraph.\n\nSecond paragraph.",
+		},
+		{
+			name: "unformatted label keeps lines",
+			raw:  "Agent response:\nline one\n\n    indented\nline three",
+			sub:  "line one\n\n    indented\nline three",
+		},
+		{
+			name: "no labels",
+			raw:  "  Just an answer.\n",
+			sub:  "Just an answer.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			main, sub := ExtractAgentResponses(tt.raw)
+			if main != tt.main {
+				t.Errorf("main = %q, want %q", main, tt.main)
+			}
+			if sub != tt.sub {
+				t.Errorf("sub = %q, want %q", sub, tt.sub)
+			}
+		})
+	}
+}
+
+func TestExtractAgentResponsesNotTruncated(t *testing.T) {
+	raw := "**Agent's response:**\nHere is code:\n\n```go\nx := 1\n```\n\n---\n\nDone."
+	_, sub := ExtractAgentResponses(raw)
+	if looksTruncated(sub) {
+		t.Errorf("response %q looks truncated", sub)
+	}
+}
@@ -0,0 +1,382 @@
+package analysis
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Plan step and agent assignment patterns
+var (
+	stepHeadingRegex  = regexp.MustCompile(`^\s*(?:#{1,6}\s+|\*\*)\s*(?i:step|phase)\s+\d+\s*[:.)\-–—]?\s*(.*?)\s*(?:\*\*)?\s*$`)
+	numberedItemRegex = regexp.MustCompile(`^(\s*)(?:\*\*)?(\d+)[.)]\s+(.+)$`)
+	bulletItemRegex   = regexp.MustCompile(`^(\s*)[-*+]\s+(.+)$`)
+	agentLabelRegex   = regexp.MustCompile("(?i)\\*{0,2}(?:sub)?agents?\\*{0,2}\\s*:\\s*\\*{0,2}\\s*`?([A-Za-z][\\w-]*)")
+	assignedToRegex   = regexp.MustCompile("(?i)\\b(?:assigned to|delegated? to|handled by|owner:)\\s+(?:the\\s+)?`?([A-Za-z][\\w-]*)")
+	namedAgentRegex   = regexp.MustCompile("(?i)`?\\b([a-z][a-z0-9]*(?:-[a-z0-9]+)+)\\b`?\\s+(?:sub)?agent\\b|(?:sub)?agent\\s+`([^`\\s]+)`")
+	kebabNameRegex    = regexp.MustCompile("`([a-z][a-z0-9]*(?:-[a-z0-9]+)+)`")
+	markdownRegex     = regexp.MustCompile("[*_`#]+")
+	agentAsideRegex   = regexp.MustCompile(`(?i)\s*\([^)]*\bagent\b[^)]*\)|\s*\[[^\]]*\bagent\b[^\]]*\]`)
+	titleTrimRegex    = regexp.MustCompile(`[\s\-–—:,|]+$`)
+)
+
+// PlanStep is one step of an implementation plan
+type PlanStep struct {
+	Title string
+	Agent string // agent assigned to the step, empty when none is named
+}
+
+// PlanOptions configures plan-structure analysis
+type PlanOptions struct {
+	Enabled        bool
+	MatchThreshold float64 // minimum title similarity for two steps to align
+}
+
+// DefaultPlanOptions returns disabled plan analysis with a step match
+// threshold of 0.3
+func DefaultPlanOptions() PlanOptions {
+	return PlanOptions{MatchThreshold: 0.3}
+}
+
+// PlanAlignment is a step-by-step alignment of two plans
+type PlanAlignment struct {
+	Pairs          [][2]int // aligned step indices in the first and second plan
+	Score          float64  // 2 * summed title similarity / total steps
+	AgentAgreement float64  // share of aligned pairs naming an agent that agree on it
+	AgentPairs     int      // aligned pairs where both steps name an agent
+}
+
+// LoopPlan is the plan found in one loop's response, compared with the
+// reference plan
+type LoopPlan struct {
+	Loop           int
+	Steps          []PlanStep
+	Alignment      float64
+	AgentAgreement float64
+	Missing        []string // reference steps with no counterpart
+	Extra          []string // steps with no counterpart in the reference
+	Reassigned     []string // aligned steps assigned to a different agent
+}
+
+// PlanSummary measures how stable the structure of the plans is
+type PlanSummary struct {
+	LoopsWithPlan    int
+	LoopsWithoutPlan []int
+	MeanSteps        float64
+	StepStdDev       float64
+	StepCV           float64
+	MinSteps         int
+	MaxSteps         int
+	MeanAlignment    float64 // mean pairwise alignment score
+	AgentAgreement   float64 // agreement over all pairwise aligned steps naming agents
+	ReferenceLoop    int     // loop whose plan aligns best with the others
+	ReferenceSteps   []PlanStep
+	AgentAssignments []AgentCallCount // steps assigned to each agent
+	UnassignedSteps  int
+	Loops            []LoopPlan
+}
+
+// ExtractPlan returns the ordered steps of a plan in markdown, or nil when
+// there are fewer than two. Headings or bold lines of the form "Step N" take
+// precedence, then the outermost numbered list, then the outermost bullet
+// list. The agent of a step is looked for in its title and then in the lines
+// up to the next step.
+func ExtractPlan(text string) []PlanStep {
+	lines := strings.Split(text, "\n")
+
+	type marker struct {
+		line  int
+		title string
+	}
+	var headings, numbered, bullets []marker
+	numberedIndent, bulletIndent := math.MaxInt32, math.MaxInt32
+	for i, line := range lines {
+		if matches := stepHeadingRegex.FindStringSubmatch(line); matches != nil {
+			headings = append(headings, marker{i, matches[1]})
+		} else if matches := numberedItemRegex.FindStringSubmatch(line); matches != nil {
+			if indent := len(matches[1]); indent < numberedIndent {
+				numberedIndent, numbered = indent, nil
+			}
+			if len(matches[1]) == numberedIndent {
+				numbered = append(numbered, marker{i, matches[3]})
+			}
+		} else if matches := bulletItemRegex.FindStringSubmatch(line); matches != nil {
+			if indent := len(matches[1]); indent < bulletIndent {
+				bulletIndent, bullets = indent, nil
+			}
+			if len(matches[1]) == bulletIndent {
+				bullets = append(bullets, marker{i, matches[2]})
+			}
+		}
+	}
+
+	markers := bullets
+	switch {
+	case len(headings) >= 2:
+		markers = headings
+	case len(numbered) >= 2:
+		markers = numbered
+	}
+	if len(markers) < 2 {
+		return nil
+	}
+
+	var steps []PlanStep
+	for k, m := range markers {
+		end := len(lines)
+		if k+1 < len(markers) {
+			end = markers[k+1].line
+		}
+		agent := findAgent(m.title)
+		if agent == "" {
+			agent = findAgent(strings.Join(lines[m.line+1:end], "\n"))
+		}
+		steps = append(steps, PlanStep{Title: stepTitle(m.title), Agent: agent})
+	}
+	return steps
+}
+
+// stepTitle strips markdown and the agent assignment from a step line
+func stepTitle(line string) string {
+	for _, pattern := range []*regexp.Regexp{agentLabelRegex, assignedToRegex} {
+		if loc := pattern.FindStringIndex(line); loc != nil && loc[0] > 0 {
+			line = line[:loc[0]]
+		}
+	}
+	line = agentAsideRegex.ReplaceAllString(line, "")
+	line = markdownRegex.ReplaceAllString(line, "")
+	return strings.TrimSpace(titleTrimRegex.ReplaceAllString(line, ""))
+}
+
+// findAgent returns the first agent named in a text
+func findAgent(text string) string {
+	for _, pattern := range []*regexp.Regexp{agentLabelRegex, assignedToRegex, namedAgentRegex, kebabNameRegex} {
+		if matches := pattern.FindStringSubmatch(text); matches != nil {
+			for _, name := range matches[1:] {
+				if name != "" {
+					return strings.ToLower(strings.Trim(name, "`*"))
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// AlignPlans aligns two plans with Needleman-Wunsch, scoring aligned steps by
+// title similarity. Steps less similar than threshold cannot align, and gaps
+// cost nothing, so the alignment keeps the most similar steps in order.
+func AlignPlans(a, b []PlanStep, threshold float64) PlanAlignment {
+	n, m := len(a), len(b)
+	if n == 0 && m == 0 {
+		return PlanAlignment{Score: 1, AgentAgreement: 1}
+	}
+
+	similarity := make([][]float64, n)
+	for i := range similarity {
+		similarity[i] = make([]float64, m)
+		for j := range similarity[i] {
+			similarity[i][j] = OverallSimilarity(strings.ToLower(a[i].Title), strings.ToLower(b[j].Title))
+		}
+	}
+
+	score := make([][]float64, n+1)
+	for i := range score {
+		score[i] = make([]float64, m+1)
+	}
+	for i := 1; i <= n; i++ {
+		for j := 1; j <= m; j++ {
+			best := math.Max(score[i-1][j], score[i][j-1])
+			if s := similarity[i-1][j-1]; s >= threshold {
+				best = math.Max(best, score[i-1][j-1]+s)
+			}
+			score[i][j] = best
+		}
+	}
+
+	// Trace back the aligned pairs
+	var alignment PlanAlignment
+	total := 0.0
+	for i, j := n, m; i > 0 && j > 0; {
+		s := similarity[i-1][j-1]
+		switch {
+		case s >= threshold && score[i][j] == score[i-1][j-1]+s:
+			alignment.Pairs = append([][2]int{{i - 1, j - 1}}, alignment.Pairs...)
+			total += s
+			i, j = i-1, j-1
+		case score[i][j] == score[i-1][j]:
+			i--
+		default:
+			j--
+		}
+	}
+	alignment.Score = 2 * total / float64(n+m)
+
+	agreed := 0
+	for _, pair := range alignment.Pairs {
+		agentA, agentB := a[pair[0]].Agent, b[pair[1]].Agent
+		if agentA == "" || agentB == "" {
+			continue
+		}
+		alignment.AgentPairs++
+		if agentA == agentB {
+			agreed++
+		}
+	}
+	alignment.AgentAgreement = 1
+	if alignment.AgentPairs > 0 {
+		alignment.AgentAgreement = float64(agreed) / float64(alignment.AgentPairs)
+	}
+	return alignment
+}
+
+// AnalyzePlans extracts the plan from every response and measures how
+// consistent their structure is, returning nil when fewer than two responses
+// contain a plan
+func AnalyzePlans(entries []LogEntry, opts PlanOptions) *PlanSummary {
+	summary := &PlanSummary{}
+	var plans [][]PlanStep
+	var loops []int
+	for _, entry := range entries {
+		steps := ExtractPlan(getResponseFromEntry(entry))
+		if len(steps) == 0 {
+			summary.LoopsWithoutPlan = append(summary.LoopsWithoutPlan, entry.Loop)
+			continue
+		}
+		plans = append(plans, steps)
+		loops = append(loops, entry.Loop)
+	}
+	n := len(plans)
+	if n < 2 {
+		return nil
+	}
+	summary.LoopsWithPlan = n
+
+	// Step counts
+	counts := make([]float64, n)
+	summary.MinSteps, summary.MaxSteps = len(plans[0]), len(plans[0])
+	for i, steps := range plans {
+		counts[i] = float64(len(steps))
+		if len(steps) < summary.MinSteps {
+			summary.MinSteps = len(steps)
+		}
+		if len(steps) > summary.MaxSteps {
+			summary.MaxSteps = len(steps)
+		}
+	}
+	summary.MeanSteps, summary.StepStdDev = meanStdDev(counts)
+	if summary.MeanSteps > 0 {
+		summary.StepCV = summary.StepStdDev / summary.MeanSteps
+	}
+
+	// Pairwise alignments
+	alignments := make([][]PlanAlignment, n)
+	for i := range alignments {
+		alignments[i] = make([]PlanAlignment, n)
+	}
+	scores := make([]float64, n)
+	agreed, agentPairs := 0.0, 0
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			alignment := AlignPlans(plans[i], plans[j], opts.MatchThreshold)
+			alignments[i][j] = alignment
+			scores[i] += alignment.Score
+			scores[j] += alignment.Score
+			summary.MeanAlignment += alignment.Score
+			agreed += alignment.AgentAgreement * float64(alignment.AgentPairs)
+			agentPairs += alignment.AgentPairs
+		}
+	}
+	summary.MeanAlignment /= float64(n * (n - 1) / 2)
+	summary.AgentAgreement = 1
+	if agentPairs > 0 {
+		summary.AgentAgreement = agreed / float64(agentPairs)
+	}
+
+	// The reference plan is the medoid under alignment score
+	reference := 0
+	for i := range scores {
+		if scores[i] > scores[reference] {
+			reference = i
+		}
+	}
+	summary.ReferenceLoop = loops[reference]
+	summary.ReferenceSteps = plans[reference]
+
+	assignments := make(map[string]*AgentCallCount)
+	for i, steps := range plans {
+		loop := LoopPlan{Loop: loops[i], Steps: steps}
+		seen := make(map[string]bool)
+		for _, step := range steps {
+			if step.Agent == "" {
+				summary.UnassignedSteps++
+				continue
+			}
+			count, ok := assignments[step.Agent]
+			if !ok {
+				count = &AgentCallCount{Agent: step.Agent}
+				assignments[step.Agent] = count
+			}
+			count.Calls++
+			if !seen[step.Agent] {
+				seen[step.Agent] = true
+				count.Loops++
+			}
+		}
+
+		var alignment PlanAlignment
+		switch {
+		case i == reference:
+			alignment = AlignPlans(steps, steps, opts.MatchThreshold)
+		case i < reference:
+			alignment = alignments[i][reference]
+		default:
+			alignment = alignments[reference][i]
+			alignment.Pairs = swapPairs(alignment.Pairs)
+		}
+		loop.Alignment = alignment.Score
+		loop.AgentAgreement = alignment.AgentAgreement
+
+		matched := make(map[int]bool)
+		matchedReference := make(map[int]bool)
+		for _, pair := range alignment.Pairs {
+			matched[pair[0]] = true
+			matchedReference[pair[1]] = true
+			step, referenceStep := steps[pair[0]], summary.ReferenceSteps[pair[1]]
+			if step.Agent != "" && referenceStep.Agent != "" && step.Agent != referenceStep.Agent {
+				loop.Reassigned = append(loop.Reassigned, step.Title)
+			}
+		}
+		for k, step := range steps {
+			if !matched[k] {
+				loop.Extra = append(loop.Extra, step.Title)
+			}
+		}
+		for k, step := range summary.ReferenceSteps {
+			if !matchedReference[k] {
+				loop.Missing = append(loop.Missing, step.Title)
+			}
+		}
+		summary.Loops = append(summary.Loops, loop)
+	}
+
+	for _, count := range assignments {
+		summary.AgentAssignments = append(summary.AgentAssignments, *count)
+	}
+	sort.Slice(summary.AgentAssignments, func(i, j int) bool {
+		if summary.AgentAssignments[i].Calls != summary.AgentAssignments[j].Calls {
+			return summary.AgentAssignments[i].Calls > summary.AgentAssignments[j].Calls
+		}
+		return summary.AgentAssignments[i].Agent < summary.AgentAssignments[j].Agent
+	})
+	return summary
+}
+
+// swapPairs exchanges the sides of aligned index pairs
+func swapPairs(pairs [][2]int) [][2]int {
+	swapped := make([][2]int, len(pairs))
+	for i, pair := range pairs {
+		swapped[i] = [2]int{pair[1], pair[0]}
+	}
+	return swapped
+}
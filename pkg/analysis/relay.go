@@ -0,0 +1,302 @@
+package analysis
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Patterns locating the instruction meant for the subagent in a prompt
+var (
+	askInstructionRegex = regexp.MustCompile(`(?is)\bask (?:it|them|the agent|the subagent)\s+to\s+(.+?)(?:,\s*(?:and\s+)?return\b|\.\s|$)`)
+	useInstructionRegex = regexp.MustCompile(`(?is)\buse (?:the\s+)?\S+\s+(?:agent\s+|subagent\s+)?to\s+(.+)$`)
+	metaClauseRegex     = regexp.MustCompile(`(?i)\b(return|report|tell me|show me) (what|how|its|the agent|their)\b|what you told|its response`)
+	clauseSplitRegex    = regexp.MustCompile(`\n+|[.;:!?](?:\s+|$)|,\s+(?:and|then)\s+|\s+and then\s+`)
+	listMarkerRegex     = regexp.MustCompile(`(?m)^\s*(?:[-*•]|\d+[.)])\s+`)
+	quotedLiteralRegex  = regexp.MustCompile(`"([^"\n]+)"|“([^”\n]+)”|(?:^|[\s(])'([^'\n]+)'(?:$|[\s.,;:!?)])`)
+)
+
+// relayStopwords are words that carry no instruction content
+var relayStopwords = map[string]bool{
+	"a": true, "an": true, "the": true, "and": true, "or": true, "to": true, "of": true,
+	"in": true, "on": true, "for": true, "with": true, "it": true, "its": true, "is": true,
+	"be": true, "me": true, "you": true, "your": true, "i": true, "my": true, "we": true,
+	"this": true, "that": true, "please": true, "just": true, "can": true, "could": true,
+	"would": true, "will": true, "should": true, "then": true, "so": true, "as": true,
+	"at": true, "by": true, "from": true, "all": true, "any": true, "do": true, "us": true,
+}
+
+// RelayOptions configures the relay fidelity check
+type RelayOptions struct {
+	Instruction string  // instruction the subagent should receive; empty extracts it from each prompt
+	MinCoverage float64 // share of a clause's terms that must appear for it to count as relayed
+}
+
+// DefaultRelayOptions returns options that extract the instruction from the
+// prompt and require half of each clause's terms
+func DefaultRelayOptions() RelayOptions {
+	return RelayOptions{MinCoverage: 0.5}
+}
+
+// RelayCheck compares one loop's instruction, the message the main agent
+// reports sending, and the subagent's reply
+type RelayCheck struct {
+	Loop                int
+	Instruction         string
+	Relayed             string
+	Reply               string
+	Coverage            float64 // share of instruction terms present in the relayed message
+	Drift               float64 // 1 - Jaccard similarity of the instruction and relayed terms
+	DroppedTerms        []string
+	AddedTerms          []string
+	DroppedInstructions []string // instruction clauses missing from the relayed message
+	AddedInstructions   []string // relayed clauses not found in the instruction
+	DroppedLiterals     []string // quoted instruction text missing from the relayed message
+	ReplyCoverage       float64  // share of relayed terms addressed by the reply
+	ReplyMissing        []string // quoted relayed text missing from the reply
+	Faithful            bool     // nothing dropped from the instruction
+}
+
+// TermCount is how many loops a term was dropped or added in
+type TermCount struct {
+	Term  string
+	Loops int
+}
+
+// RelaySummary is the relay fidelity of a run
+type RelaySummary struct {
+	Checked           int
+	SkippedLoops      []int // loops without a relayed message
+	Faithful          int
+	FaithfulRate      float64
+	MeanCoverage      float64
+	MeanDrift         float64
+	MeanReplyCoverage float64
+	Dropped           []TermCount // terms dropped most often
+	Added             []TermCount // terms added most often
+	Loops             []RelayCheck
+}
+
+// ExtractInstruction returns the part of a prompt meant to be passed on to
+// the subagent, such as "say 'hello'" in "use the X agent and ask it to say
+// 'hello', return what you told the agent...". Prompts without a recognised
+// delegation phrase are returned whole, minus clauses about reporting back.
+func ExtractInstruction(prompt string) string {
+	instruction := prompt
+	if matches := askInstructionRegex.FindStringSubmatch(prompt); matches != nil {
+		instruction = matches[1]
+	} else if matches := useInstructionRegex.FindStringSubmatch(prompt); matches != nil {
+		instruction = matches[1]
+	}
+
+	var kept []string
+	for _, clause := range splitClauses(instruction) {
+		if !metaClauseRegex.MatchString(clause) {
+			kept = append(kept, clause)
+		}
+	}
+	if len(kept) == 0 {
+		return strings.TrimSpace(instruction)
+	}
+	return strings.Join(kept, ". ")
+}
+
+// CheckRelay compares an instruction with the message relayed by the main
+// agent and the subagent's reply
+func CheckRelay(instruction, relayed, reply string, minCoverage float64) RelayCheck {
+	check := RelayCheck{Instruction: instruction, Relayed: relayed, Reply: reply}
+
+	instructionTerms := relayTerms(instruction)
+	relayedTerms := relayTerms(relayed)
+	check.Coverage = termCoverage(instructionTerms, relayedTerms)
+	check.DroppedTerms = termDifference(instructionTerms, relayedTerms)
+	check.AddedTerms = termDifference(relayedTerms, instructionTerms)
+	check.Drift = 1 - termJaccard(instructionTerms, relayedTerms)
+
+	for _, clause := range splitClauses(instruction) {
+		if terms := relayTerms(clause); len(terms) > 0 && termCoverage(terms, relayedTerms) < minCoverage {
+			check.DroppedInstructions = append(check.DroppedInstructions, clause)
+		}
+	}
+	for _, clause := range splitClauses(relayed) {
+		if terms := relayTerms(clause); len(terms) > 0 && termCoverage(terms, instructionTerms) < minCoverage {
+			check.AddedInstructions = append(check.AddedInstructions, clause)
+		}
+	}
+	check.DroppedLiterals = missingLiterals(instruction, relayed)
+
+	if reply != "" {
+		check.ReplyCoverage = termCoverage(relayedTerms, relayTerms(reply))
+		check.ReplyMissing = missingLiterals(relayed, reply)
+		// A reply that produces every quoted literal addresses the request,
+		// however little else of the request's wording it repeats
+		if len(quotedLiterals(relayed)) > 0 && len(check.ReplyMissing) == 0 {
+			check.ReplyCoverage = 1
+		}
+	}
+
+	check.Faithful = len(check.DroppedInstructions) == 0 && len(check.DroppedLiterals) == 0
+	return check
+}
+
+// AnalyzeRelay checks every loop whose response reports the message sent to
+// the subagent, returning nil when no loop does
+func AnalyzeRelay(entries []LogEntry, opts RelayOptions) *RelaySummary {
+	summary := &RelaySummary{}
+	dropped := make(map[string]int)
+	added := make(map[string]int)
+
+	for _, entry := range entries {
+		if strings.TrimSpace(entry.MainAgentResponse) == "" {
+			summary.SkippedLoops = append(summary.SkippedLoops, entry.Loop)
+			continue
+		}
+		instruction := opts.Instruction
+		if instruction == "" {
+			instruction = ExtractInstruction(entry.Prompt)
+		}
+
+		check := CheckRelay(instruction, entry.MainAgentResponse, entry.SubAgentResponse, opts.MinCoverage)
+		check.Loop = entry.Loop
+		summary.Loops = append(summary.Loops, check)
+		summary.Checked++
+		summary.MeanCoverage += check.Coverage
+		summary.MeanDrift += check.Drift
+		summary.MeanReplyCoverage += check.ReplyCoverage
+		if check.Faithful {
+			summary.Faithful++
+		}
+		for _, term := range check.DroppedTerms {
+			dropped[term]++
+		}
+		for _, term := range check.AddedTerms {
+			added[term]++
+		}
+	}
+	if summary.Checked == 0 {
+		return nil
+	}
+
+	n := float64(summary.Checked)
+	summary.FaithfulRate = float64(summary.Faithful) / n
+	summary.MeanCoverage /= n
+	summary.MeanDrift /= n
+	summary.MeanReplyCoverage /= n
+	summary.Dropped = sortedTermCounts(dropped)
+	summary.Added = sortedTermCounts(added)
+	return summary
+}
+
+// splitClauses splits text into sentences, lines and list items
+func splitClauses(text string) []string {
+	var clauses []string
+	text = listMarkerRegex.ReplaceAllString(text, "")
+	for _, part := range clauseSplitRegex.Split(text, -1) {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			clauses = append(clauses, part)
+		}
+	}
+	return clauses
+}
+
+// relayTerms returns the distinct content words of a text, lowercased and
+// crudely singularised
+func relayTerms(text string) map[string]bool {
+	terms := make(map[string]bool)
+	for _, word := range tokenize(strings.ToLower(text)) {
+		if relayStopwords[word] {
+			continue
+		}
+		if len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") {
+			word = strings.TrimSuffix(word, "s")
+		}
+		terms[word] = true
+	}
+	return terms
+}
+
+// termCoverage is the share of want's terms present in have, 1 when want is
+// empty
+func termCoverage(want, have map[string]bool) float64 {
+	if len(want) == 0 {
+		return 1
+	}
+	found := 0
+	for term := range want {
+		if have[term] {
+			found++
+		}
+	}
+	return float64(found) / float64(len(want))
+}
+
+// termJaccard is the Jaccard similarity of two term sets
+func termJaccard(a, b map[string]bool) float64 {
+	union := len(b)
+	shared := 0
+	for term := range a {
+		if b[term] {
+			shared++
+		} else {
+			union++
+		}
+	}
+	if union == 0 {
+		return 1
+	}
+	return float64(shared) / float64(union)
+}
+
+// termDifference returns the sorted terms of a missing from b
+func termDifference(a, b map[string]bool) []string {
+	var missing []string
+	for term := range a {
+		if !b[term] {
+			missing = append(missing, term)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// quotedLiterals returns the quoted strings in a text
+func quotedLiterals(text string) []string {
+	var literals []string
+	for _, matches := range quotedLiteralRegex.FindAllStringSubmatch(text, -1) {
+		for _, literal := range matches[1:] {
+			if literal != "" {
+				literals = append(literals, literal)
+			}
+		}
+	}
+	return literals
+}
+
+// missingLiterals returns the quoted strings of source that do not appear in
+// target, ignoring case
+func missingLiterals(source, target string) []string {
+	var missing []string
+	lower := strings.ToLower(target)
+	for _, literal := range quotedLiterals(source) {
+		if !strings.Contains(lower, strings.ToLower(literal)) {
+			missing = append(missing, literal)
+		}
+	}
+	return missing
+}
+
+// sortedTermCounts orders term counts by frequency, then alphabetically
+func sortedTermCounts(counts map[string]int) []TermCount {
+	terms := make([]TermCount, 0, len(counts))
+	for term, loops := range counts {
+		terms = append(terms, TermCount{Term: term, Loops: loops})
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if terms[i].Loops != terms[j].Loops {
+			return terms[i].Loops > terms[j].Loops
+		}
+		return terms[i].Term < terms[j].Term
+	})
+	return terms
+}
@@ -0,0 +1,1123 @@
+package analysis
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// ReportSchemaVersion is the version of the JSON report schema. Adding
+// optional fields keeps the version; renaming or removing fields bumps it.
+//
+// Fields added within version 1:
+//   - metadata: agent, template, loops and start time from the log header
+const ReportSchemaVersion = "1"
+
+// Report is the machine-readable form of a DualAgentAnalysisResult
+type Report struct {
+	SchemaVersion string            `json:"schema_version"`
+	GeneratedAt   time.Time         `json:"generated_at"`
+	Source        string            `json:"source"`
+	Metadata      ReportMetadata    `json:"metadata"`
+	Warnings      []string          `json:"parse_warnings,omitempty"`
+	TotalEntries  int               `json:"total_entries"`
+	Entries       []ReportEntry     `json:"entries"`
+	MainAgent     *AgentReport      `json:"main_agent"`
+	SubAgent      *AgentReport      `json:"sub_agent"`
+	Latency       *ReportLatency    `json:"latency,omitempty"`
+	Errors        *ReportErrors     `json:"errors,omitempty"`
+	Delegation    *ReportDelegation `json:"delegation,omitempty"`
+	Relay         *ReportRelay      `json:"relay,omitempty"`
+}
+
+// ReportMetadata is the run header the test runner writes at the top of a
+// log. Logs written before headers were added leave it empty.
+type ReportMetadata struct {
+	Agent    string     `json:"agent"`
+	Template string     `json:"template"`
+	Loops    int        `json:"loops"`
+	Started  *time.Time `json:"started,omitempty"`
+}
+
+// ReportRelay is the relay fidelity of a run
+type ReportRelay struct {
+	Checked           int               `json:"checked"`
+	SkippedLoops      []int             `json:"skipped_loops"`
+	Faithful          int               `json:"faithful"`
+	FaithfulRate      float64           `json:"faithful_rate"`
+	MeanCoverage      float64           `json:"mean_coverage"`
+	MeanDrift         float64           `json:"mean_drift"`
+	MeanReplyCoverage float64           `json:"mean_reply_coverage"`
+	Dropped           []ReportTermCount `json:"dropped_terms"`
+	Added             []ReportTermCount `json:"added_terms"`
+	Loops             []ReportRelayLoop `json:"loops"`
+}
+
+// ReportTermCount is how many loops a term was dropped or added in
+type ReportTermCount struct {
+	Term  string `json:"term"`
+	Loops int    `json:"loops"`
+}
+
+// ReportRelayLoop is the relay check of one loop
+type ReportRelayLoop struct {
+	Loop                int      `json:"loop"`
+	Instruction         string   `json:"instruction"`
+	Relayed             string   `json:"relayed"`
+	Reply               string   `json:"reply"`
+	Coverage            float64  `json:"coverage"`
+	Drift               float64  `json:"drift"`
+	DroppedTerms        []string `json:"dropped_terms"`
+	AddedTerms          []string `json:"added_terms"`
+	DroppedInstructions []string `json:"dropped_instructions"`
+	AddedInstructions   []string `json:"added_instructions"`
+	DroppedLiterals     []string `json:"dropped_literals"`
+	ReplyCoverage       float64  `json:"reply_coverage"`
+	ReplyMissing        []string `json:"reply_missing"`
+	Faithful            bool     `json:"faithful"`
+}
+
+// ReportDelegation checks that loops called the expected subagent
+type ReportDelegation struct {
+	ExpectedAgent     string                 `json:"expected_agent"`
+	TracedLoops       int                    `json:"traced_loops"`
+	UntracedLoops     int                    `json:"untraced_loops"`
+	LoopsInvoked      int                    `json:"loops_invoked"`
+	InvocationRate    float64                `json:"invocation_rate"`
+	NoCallLoops       []int                  `json:"no_call_loops"`
+	MismatchLoops     []int                  `json:"mismatch_loops"`
+	RepeatedCallLoops []int                  `json:"repeated_call_loops"`
+	Agents            []ReportAgentCallCount `json:"agents"`
+	Loops             []ReportLoopDelegation `json:"loops"`
+}
+
+// ReportAgentCallCount is how often one subagent was called
+type ReportAgentCallCount struct {
+	Agent string `json:"agent"`
+	Calls int    `json:"calls"`
+	Loops int    `json:"loops"`
+}
+
+// ReportLoopDelegation is the subagent activity of one loop
+type ReportLoopDelegation struct {
+	Loop          int      `json:"loop"`
+	SubagentCalls int      `json:"subagent_calls"`
+	Agents        []string `json:"agents"`
+	Invoked       bool     `json:"invoked"`
+	OtherAgents   bool     `json:"other_agents"`
+}
+
+// ReportLatency is the distribution of execution times in seconds
+type ReportLatency struct {
+	Samples     int     `json:"samples"`
+	Min         float64 `json:"min"`
+	Median      float64 `json:"median"`
+	P90         float64 `json:"p90"`
+	P99         float64 `json:"p99"`
+	Max         float64 `json:"max"`
+	Mean        float64 `json:"mean"`
+	StdDev      float64 `json:"std_dev"`
+	CV          float64 `json:"coefficient_of_variation"`
+	SlowestLoop int     `json:"slowest_loop"`
+}
+
+// ReportErrors categorises the stderr output of a run
+type ReportErrors struct {
+	LoopsWithErrors    int                `json:"loops_with_errors"`
+	ErrorRate          float64            `json:"error_rate"`
+	Classes            []ReportErrorClass `json:"classes"`
+	EmptyResponseLoops []int              `json:"empty_response_loops"`
+}
+
+// ReportErrorClass is how often one class of error occurred
+type ReportErrorClass struct {
+	Class    string `json:"class"`
+	Messages int    `json:"messages"`
+	Loops    []int  `json:"loops"`
+	Example  string `json:"example"`
+}
+
+// ReportLatencyCorrelation relates an agent's latency to its responses
+type ReportLatencyCorrelation struct {
+	Samples        int                    `json:"samples"`
+	LengthPearson  float64                `json:"length_pearson"`
+	LengthSpearman float64                `json:"length_spearman"`
+	ClusterEffect  float64                `json:"cluster_eta_squared"`
+	Clusters       []ReportClusterLatency `json:"clusters"`
+}
+
+// ReportClusterLatency is the latency of one cluster's responses
+type ReportClusterLatency struct {
+	Cluster int     `json:"cluster"`
+	Samples int     `json:"samples"`
+	Median  float64 `json:"median"`
+	Mean    float64 `json:"mean"`
+}
+
+// ReportJudgments is the judge's grading of responses against a reference
+type ReportJudgments struct {
+	Reference  string              `json:"reference"`
+	Judged     int                 `json:"judged"`
+	Equivalent int                 `json:"equivalent"`
+	Rate       float64             `json:"rate"`
+	MeanScore  float64             `json:"mean_score"`
+	Results    []ReportJudgeResult `json:"results"`
+}
+
+// ReportJudgeResult is the verdict for one loop
+type ReportJudgeResult struct {
+	Loop       int     `json:"loop"`
+	Equivalent bool    `json:"equivalent"`
+	Score      float64 `json:"score"`
+	Reason     string  `json:"reason,omitempty"`
+}
+
+// ReportCode is the code block analysis of an agent's responses
+type ReportCode struct {
+	LoopsWithCode   int                   `json:"loops_with_code"`
+	Languages       []ReportLanguageCount `json:"languages"`
+	CodeSimilarity  float64               `json:"code_similarity"`
+	ProseSimilarity float64               `json:"prose_similarity"`
+	GoBlocks        int                   `json:"go_blocks"`
+	GoParsed        int                   `json:"go_parsed"`
+	GoFormatted     int                   `json:"go_formatted"`
+	GoVetted        int                   `json:"go_vetted"`
+	GoVetClean      int                   `json:"go_vet_clean"`
+	Loops           []ReportLoopCode      `json:"loops"`
+}
+
+// ReportLanguageCount is how often a language appeared
+type ReportLanguageCount struct {
+	Language string `json:"language"`
+	Blocks   int    `json:"blocks"`
+	Loops    int    `json:"loops"`
+}
+
+// ReportLoopCode is the code found in one loop
+type ReportLoopCode struct {
+	Loop      int             `json:"loop"`
+	Languages []string        `json:"languages"` // language of each block
+	Go        []ReportGoCheck `json:"go,omitempty"`
+}
+
+// ReportGoCheck is the result of checking one Go block
+type ReportGoCheck struct {
+	Block      int    `json:"block"`
+	Fragment   string `json:"fragment"`
+	Parses     bool   `json:"parses"`
+	ParseError string `json:"parse_error,omitempty"`
+	Formatted  bool   `json:"gofmt_clean"`
+	Vetted     bool   `json:"vetted"`
+	VetClean   bool   `json:"vet_clean"`
+	VetOutput  string `json:"vet_output,omitempty"`
+}
+
+// ReportEntry is a single parsed log entry
+type ReportEntry struct {
+	Loop              int        `json:"loop"`
+	Timestamp         time.Time  `json:"timestamp"`
+	Prompt            string     `json:"prompt"`
+	MainAgentResponse string     `json:"main_agent_response"`
+	SubAgentResponse  string     `json:"sub_agent_response"`
+	RawResponse       string     `json:"raw_response"`
+	Errors            string     `json:"errors,omitempty"`
+	ExecutionSeconds  float64    `json:"execution_seconds"`
+	ToolTrace         bool       `json:"tool_trace,omitempty"`
+	ToolCalls         []ToolCall `json:"tool_calls,omitempty"`
+}
+
+// AgentReport holds the analysis of one agent's responses
+type AgentReport struct {
+	TotalResponses    int                       `json:"total_responses"`
+	ResponseLoops     []int                     `json:"response_loops"` // loop of each response, by matrix index
+	Responses         []string                  `json:"responses"`      // text of each response, by matrix index
+	SimilarityMetric  string                    `json:"similarity_metric"`
+	AverageSimilarity float64                   `json:"average_similarity"`
+	Answers           *ReportAnswers            `json:"answers,omitempty"`
+	Constraints       *ReportConstraints        `json:"format_constraints,omitempty"`
+	SimilarityMatrix  [][]float64               `json:"similarity_matrix"`
+	ClusterMethod     string                    `json:"cluster_method"`
+	Silhouette        float64                   `json:"silhouette"`
+	Clusters          []ReportCluster           `json:"clusters"`
+	MostCommonPattern string                    `json:"most_common_pattern"`
+	MostCommonCount   int                       `json:"most_common_count"`
+	MostAbnormal      *ReportAbnormal           `json:"most_abnormal"`
+	OutlierMethod     string                    `json:"outlier_method"`
+	OutlierThreshold  float64                   `json:"outlier_threshold"`
+	AnomalyScores     []ReportAnomalyScore      `json:"anomaly_scores"`
+	Outliers          []ReportOutlier           `json:"outliers"`
+	Assertions        *ReportAssertions         `json:"assertions,omitempty"`
+	Behaviour         *ReportBehaviour          `json:"behaviour,omitempty"`
+	Intervals         *ReportIntervals          `json:"confidence_intervals,omitempty"`
+	Reliability       *ReportReliability        `json:"reliability,omitempty"`
+	Latency           *ReportLatencyCorrelation `json:"latency_correlation,omitempty"`
+	Judgments         *ReportJudgments          `json:"judgments,omitempty"`
+	Code              *ReportCode               `json:"code,omitempty"`
+	Fields            *ReportFields             `json:"json_fields,omitempty"`
+	Plan              *ReportPlan               `json:"plan,omitempty"`
+}
+
+// ReportAnswers is the self-consistency of the extracted final answers
+type ReportAnswers struct {
+	Extractors     []string            `json:"extractors"`
+	Extracted      int                 `json:"extracted"`
+	ExtractionRate float64             `json:"extraction_rate"`
+	NoAnswerLoops  []int               `json:"no_answer_loops"`
+	Majority       string              `json:"majority"`
+	MajorityCount  int                 `json:"majority_count"`
+	Agreement      float64             `json:"agreement"`
+	Entropy        float64             `json:"entropy_bits"`
+	Distribution   []ReportAnswerCount `json:"distribution"`
+	DeviantLoops   []int               `json:"deviant_loops"`
+	Loops          []ReportLoopAnswer  `json:"loops"`
+}
+
+// ReportAnswerCount is how many loops gave one answer
+type ReportAnswerCount struct {
+	Answer string  `json:"answer"`
+	Loops  []int   `json:"loops"`
+	Share  float64 `json:"share"`
+}
+
+// ReportLoopAnswer is the answer extracted from one loop
+type ReportLoopAnswer struct {
+	Loop      int    `json:"loop"`
+	Raw       string `json:"raw"`
+	Answer    string `json:"answer"`
+	Extractor string `json:"extractor"`
+}
+
+// ReportConstraints is how closely responses followed the format constraints
+type ReportConstraints struct {
+	LoopsEvaluated int                         `json:"loops_evaluated"`
+	LoopsCompliant int                         `json:"loops_compliant"`
+	ComplianceRate float64                     `json:"compliance_rate"`
+	Violations     []ReportConstraintViolation `json:"violations"`
+	Loops          []ReportLoopCompliance      `json:"loops"`
+}
+
+// ReportConstraintViolation counts the violations of one constraint
+type ReportConstraintViolation struct {
+	Constraint string `json:"constraint"`
+	Evaluated  int    `json:"evaluated"`
+	Violated   int    `json:"violated"`
+	Loops      []int  `json:"loops"`
+}
+
+// ReportLoopCompliance is one loop's constraint violations
+type ReportLoopCompliance struct {
+	Loop       int      `json:"loop"`
+	Compliant  bool     `json:"compliant"`
+	Violations []string `json:"violations"`
+}
+
+// ReportBehaviour is the distribution of behaviour labels
+type ReportBehaviour struct {
+	Classified   int                    `json:"classified"`
+	AnsweredRate float64                `json:"answered_rate"`
+	Labels       []ReportBehaviourCount `json:"labels"`
+	Loops        []ReportLoopBehaviour  `json:"loops"`
+}
+
+// ReportBehaviourCount is how many loops received one label
+type ReportBehaviourCount struct {
+	Label string  `json:"label"`
+	Loops []int   `json:"loops"`
+	Rate  float64 `json:"rate"`
+}
+
+// ReportLoopBehaviour is the label of one loop and the rule that gave it
+type ReportLoopBehaviour struct {
+	Loop  int    `json:"loop"`
+	Label string `json:"label"`
+	Rule  string `json:"rule,omitempty"`
+}
+
+// ReportFields is the per-field consistency of JSON responses
+type ReportFields struct {
+	JSONLoops          int           `json:"json_loops"`
+	NonJSONLoops       []int         `json:"non_json_loops"`
+	StructureAgreement float64       `json:"structure_agreement"`
+	FlakyFields        int           `json:"flaky_fields"`
+	MeanConsistency    float64       `json:"mean_consistency"`
+	Fields             []ReportField `json:"fields"`
+}
+
+// ReportField is the consistency of one JSON field
+type ReportField struct {
+	Path           string            `json:"path"`
+	Consistency    float64           `json:"consistency"`
+	Present        int               `json:"present"`
+	PresenceRate   float64           `json:"presence_rate"`
+	MissingLoops   []int             `json:"missing_loops"`
+	Types          []ReportTypeCount `json:"types"`
+	TypeStability  float64           `json:"type_stability"`
+	Kind           string            `json:"kind"`
+	MajorityValue  string            `json:"majority_value,omitempty"`
+	ValueAgreement *float64          `json:"value_agreement,omitempty"`
+	DistinctValues int               `json:"distinct_values,omitempty"`
+	DeviantLoops   []int             `json:"deviant_loops,omitempty"`
+	SetOverlap     *float64          `json:"set_overlap,omitempty"`
+}
+
+// ReportTypeCount is how many loops a field had one JSON type in
+type ReportTypeCount struct {
+	Type  string `json:"type"`
+	Loops int    `json:"loops"`
+}
+
+// ReportPlan is the consistency of plan structure across loops
+type ReportPlan struct {
+	LoopsWithPlan    int                    `json:"loops_with_plan"`
+	LoopsWithoutPlan []int                  `json:"loops_without_plan"`
+	MeanSteps        float64                `json:"mean_steps"`
+	StepStdDev       float64                `json:"step_std_dev"`
+	StepCV           float64                `json:"step_coefficient_of_variation"`
+	MinSteps         int                    `json:"min_steps"`
+	MaxSteps         int                    `json:"max_steps"`
+	MeanAlignment    float64                `json:"mean_alignment"`
+	AgentAgreement   float64                `json:"agent_agreement"`
+	ReferenceLoop    int                    `json:"reference_loop"`
+	ReferenceSteps   []ReportPlanStep       `json:"reference_steps"`
+	AgentAssignments []ReportAgentStepCount `json:"agent_assignments"`
+	UnassignedSteps  int                    `json:"unassigned_steps"`
+	Loops            []ReportLoopPlan       `json:"loops"`
+}
+
+// ReportPlanStep is one plan step
+type ReportPlanStep struct {
+	Title string `json:"title"`
+	Agent string `json:"agent"`
+}
+
+// ReportAgentStepCount is how many plan steps were assigned to an agent
+type ReportAgentStepCount struct {
+	Agent string `json:"agent"`
+	Steps int    `json:"steps"`
+	Loops int    `json:"loops"`
+}
+
+// ReportLoopPlan is one loop's plan compared with the reference plan
+type ReportLoopPlan struct {
+	Loop           int              `json:"loop"`
+	Steps          []ReportPlanStep `json:"steps"`
+	Alignment      float64          `json:"alignment"`
+	AgentAgreement float64          `json:"agent_agreement"`
+	Missing        []string         `json:"missing"`
+	Extra          []string         `json:"extra"`
+	Reassigned     []string         `json:"reassigned"`
+}
+
+// ReportCluster is a cluster with the loops of its members
+type ReportCluster struct {
+	Size        int     `json:"size"`
+	Share       float64 `json:"share"`
+	MemberLoops []int   `json:"member_loops"`
+	MedoidLoop  int     `json:"medoid_loop"`
+	Medoid      string  `json:"medoid"`
+	Silhouette  float64 `json:"silhouette"`
+}
+
+// ReportAbnormal is the single most abnormal response
+type ReportAbnormal struct {
+	Loop     int         `json:"loop"`
+	Score    float64     `json:"score"`
+	Response string      `json:"response"`
+	Diff     *ReportDiff `json:"diff,omitempty"`
+}
+
+// ReportAnomalyScore is the anomaly score of one response
+type ReportAnomalyScore struct {
+	Loop           int     `json:"loop"`
+	MeanSimilarity float64 `json:"mean_similarity"`
+	Score          float64 `json:"score"`
+	Outlier        bool    `json:"outlier"`
+}
+
+// ReportOutlier is a flagged response and its nearest normal neighbour
+type ReportOutlier struct {
+	Loop                    int         `json:"loop"`
+	Score                   float64     `json:"score"`
+	MeanSimilarity          float64     `json:"mean_similarity"`
+	Response                string      `json:"response"`
+	NearestNormalLoop       *int        `json:"nearest_normal_loop"`
+	NearestNormalSimilarity float64     `json:"nearest_normal_similarity"`
+	Diff                    *ReportDiff `json:"diff,omitempty"`
+}
+
+// ReportDiff is a response diffed against a cluster medoid
+type ReportDiff struct {
+	ReferenceLoop int                 `json:"reference_loop"`
+	Mode          string              `json:"mode"`
+	Inserted      int                 `json:"inserted"`
+	Deleted       int                 `json:"deleted"`
+	Segments      []ReportDiffSegment `json:"segments"`
+	Text          string              `json:"text"` // with [-deleted-] and {+inserted+} markers
+}
+
+// ReportDiffSegment is a run of equal, inserted or deleted text
+type ReportDiffSegment struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+	Code bool   `json:"code,omitempty"` // lines of a fenced code block
+}
+
+// ReportAssertions summarises correctness assertions for an agent
+type ReportAssertions struct {
+	LoopsEvaluated int                      `json:"loops_evaluated"`
+	LoopsSkipped   []int                    `json:"loops_skipped"`
+	LoopsPassed    int                      `json:"loops_passed"`
+	PassRate       float64                  `json:"pass_rate"`
+	Checks         int                      `json:"checks"`
+	ChecksPassed   int                      `json:"checks_passed"`
+	CheckPassRate  float64                  `json:"check_pass_rate"`
+	Failures       []ReportAssertionFailure `json:"failures"`
+	Results        []ReportAssertionResult  `json:"results"`
+}
+
+// ReportAssertionFailure counts the failures of one assertion
+type ReportAssertionFailure struct {
+	Assertion string `json:"assertion"`
+	Evaluated int    `json:"evaluated"`
+	Failed    int    `json:"failed"`
+	Loops     []int  `json:"loops"`
+}
+
+// ReportAssertionResult is one assertion checked against one loop
+type ReportAssertionResult struct {
+	Loop      int    `json:"loop"`
+	Assertion string `json:"assertion"`
+	Passed    bool   `json:"passed"`
+	Message   string `json:"message,omitempty"`
+}
+
+// ReportIntervals holds bootstrap confidence intervals
+type ReportIntervals struct {
+	Iterations        int             `json:"iterations"`
+	TargetMargin      float64         `json:"target_margin"`
+	AverageSimilarity *ReportInterval `json:"average_similarity,omitempty"`
+	MajorityShare     *ReportInterval `json:"majority_share,omitempty"`
+	PassRate          *ReportInterval `json:"pass_rate,omitempty"`
+}
+
+// ReportInterval is a single confidence interval
+type ReportInterval struct {
+	Estimate    float64 `json:"estimate"`
+	Lower       float64 `json:"lower"`
+	Upper       float64 `json:"upper"`
+	Confidence  float64 `json:"confidence"`
+	Samples     int     `json:"samples"`
+	LoopsNeeded int     `json:"loops_needed"`
+}
+
+// ReportReliability is the graded composite reliability score
+type ReportReliability struct {
+	Grade       string                 `json:"grade"`
+	Description string                 `json:"description"`
+	Composite   float64                `json:"composite_score"`
+	Limiting    string                 `json:"limiting_metric"`
+	Explanation string                 `json:"explanation"`
+	Components  []ReportScoreComponent `json:"components"`
+}
+
+// ReportScoreComponent is one metric's contribution to the composite score
+type ReportScoreComponent struct {
+	Metric       string  `json:"metric"`
+	Value        float64 `json:"value"`
+	Score        float64 `json:"score"`
+	Weight       float64 `json:"weight"`
+	Contribution float64 `json:"contribution"`
+}
+
+// BuildReport converts an analysis result into the versioned report schema
+func BuildReport(result *DualAgentAnalysisResult, source string) *Report {
+	report := &Report{
+		SchemaVersion: ReportSchemaVersion,
+		GeneratedAt:   time.Now().UTC(),
+		Source:        source,
+		TotalEntries:  result.TotalEntries,
+		Entries:       make([]ReportEntry, 0, len(result.Entries)),
+		MainAgent:     buildAgentReport(result.MainAgentAnalysis),
+		SubAgent:      buildAgentReport(result.SubAgentAnalysis),
+	}
+
+	report.Metadata = ReportMetadata{
+		Agent:    result.Metadata.Agent,
+		Template: result.Metadata.Template,
+		Loops:    result.Metadata.Loops,
+	}
+	if started := result.Metadata.Started; !started.IsZero() {
+		report.Metadata.Started = &started
+	}
+
+	for _, warning := range result.Warnings {
+		report.Warnings = append(report.Warnings, warning.String())
+	}
+
+	if stats := result.Latency; stats != nil {
+		report.Latency = &ReportLatency{
+			Samples:     stats.Samples,
+			Min:         stats.Min,
+			Median:      stats.Median,
+			P90:         stats.P90,
+			P99:         stats.P99,
+			Max:         stats.Max,
+			Mean:        stats.Mean,
+			StdDev:      stats.StdDev,
+			CV:          stats.CV,
+			SlowestLoop: stats.SlowestLoop,
+		}
+	}
+
+	if summary := result.Errors; summary != nil {
+		errors := &ReportErrors{
+			LoopsWithErrors:    summary.LoopsWithErrors,
+			ErrorRate:          summary.ErrorRate,
+			Classes:            make([]ReportErrorClass, 0, len(summary.Classes)),
+			EmptyResponseLoops: nonNilInts(summary.EmptyResponseLoops),
+		}
+		for _, class := range summary.Classes {
+			errors.Classes = append(errors.Classes, ReportErrorClass{
+				Class:    string(class.Class),
+				Messages: class.Messages,
+				Loops:    class.Loops,
+				Example:  class.Example,
+			})
+		}
+		report.Errors = errors
+	}
+
+	for _, entry := range result.Entries {
+		report.Entries = append(report.Entries, ReportEntry{
+			Loop:              entry.Loop,
+			Timestamp:         entry.Timestamp,
+			Prompt:            entry.Prompt,
+			MainAgentResponse: entry.MainAgentResponse,
+			SubAgentResponse:  entry.SubAgentResponse,
+			RawResponse:       entry.RawResponse,
+			Errors:            entry.Errors,
+			ExecutionSeconds:  entry.ExecutionTime.Seconds(),
+			ToolTrace:         entry.ToolTrace,
+			ToolCalls:         entry.ToolCalls,
+		})
+	}
+
+	if summary := result.Delegation; summary != nil {
+		delegation := &ReportDelegation{
+			ExpectedAgent:     summary.Expected,
+			TracedLoops:       summary.TracedLoops,
+			UntracedLoops:     summary.UntracedLoops,
+			LoopsInvoked:      summary.LoopsInvoked,
+			InvocationRate:    summary.InvocationRate,
+			NoCallLoops:       nonNilInts(summary.NoCallLoops),
+			MismatchLoops:     nonNilInts(summary.MismatchLoops),
+			RepeatedCallLoops: nonNilInts(summary.RepeatedCallLoops),
+			Agents:            make([]ReportAgentCallCount, 0, len(summary.Agents)),
+			Loops:             make([]ReportLoopDelegation, 0, len(summary.Loops)),
+		}
+		for _, agent := range summary.Agents {
+			delegation.Agents = append(delegation.Agents, ReportAgentCallCount(agent))
+		}
+		for _, loop := range summary.Loops {
+			agents := loop.Agents
+			if agents == nil {
+				agents = []string{}
+			}
+			delegation.Loops = append(delegation.Loops, ReportLoopDelegation{
+				Loop:          loop.Loop,
+				SubagentCalls: loop.SubagentCalls,
+				Agents:        agents,
+				Invoked:       loop.Invoked,
+				OtherAgents:   loop.OtherAgents,
+			})
+		}
+		report.Delegation = delegation
+	}
+
+	if summary := result.Relay; summary != nil {
+		relay := &ReportRelay{
+			Checked:           summary.Checked,
+			SkippedLoops:      nonNilInts(summary.SkippedLoops),
+			Faithful:          summary.Faithful,
+			FaithfulRate:      summary.FaithfulRate,
+			MeanCoverage:      summary.MeanCoverage,
+			MeanDrift:         summary.MeanDrift,
+			MeanReplyCoverage: summary.MeanReplyCoverage,
+			Dropped:           make([]ReportTermCount, 0, len(summary.Dropped)),
+			Added:             make([]ReportTermCount, 0, len(summary.Added)),
+			Loops:             make([]ReportRelayLoop, 0, len(summary.Loops)),
+		}
+		for _, term := range summary.Dropped {
+			relay.Dropped = append(relay.Dropped, ReportTermCount(term))
+		}
+		for _, term := range summary.Added {
+			relay.Added = append(relay.Added, ReportTermCount(term))
+		}
+		for _, check := range summary.Loops {
+			relay.Loops = append(relay.Loops, ReportRelayLoop{
+				Loop:                check.Loop,
+				Instruction:         check.Instruction,
+				Relayed:             check.Relayed,
+				Reply:               check.Reply,
+				Coverage:            check.Coverage,
+				Drift:               check.Drift,
+				DroppedTerms:        nonNilStrings(check.DroppedTerms),
+				AddedTerms:          nonNilStrings(check.AddedTerms),
+				DroppedInstructions: nonNilStrings(check.DroppedInstructions),
+				AddedInstructions:   nonNilStrings(check.AddedInstructions),
+				DroppedLiterals:     nonNilStrings(check.DroppedLiterals),
+				ReplyCoverage:       check.ReplyCoverage,
+				ReplyMissing:        nonNilStrings(check.ReplyMissing),
+				Faithful:            check.Faithful,
+			})
+		}
+		report.Relay = relay
+	}
+
+	return report
+}
+
+// WriteJSONReport writes an indented JSON report
+func WriteJSONReport(w io.Writer, report *Report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// buildAgentReport converts one agent's analysis
+func buildAgentReport(result *AnalysisResult) *AgentReport {
+	if result == nil {
+		return nil
+	}
+
+	loops := responseLoops(result)
+	loopOf := func(index int) int {
+		if index >= 0 && index < len(loops) {
+			return loops[index]
+		}
+		return 0
+	}
+
+	report := &AgentReport{
+		TotalResponses:    result.TotalResponses,
+		ResponseLoops:     loops,
+		Responses:         responseTexts(result),
+		SimilarityMetric:  result.Metric,
+		AverageSimilarity: result.AverageSimilarity,
+		SimilarityMatrix:  result.SimilarityMatrix,
+		ClusterMethod:     string(result.ClusterMethod),
+		Silhouette:        result.Silhouette,
+		Clusters:          make([]ReportCluster, 0, len(result.Clusters)),
+		MostCommonPattern: result.MostCommonPattern,
+		MostCommonCount:   result.MostCommonCount,
+		OutlierMethod:     string(result.OutlierMethod),
+		OutlierThreshold:  result.OutlierThreshold,
+		AnomalyScores:     make([]ReportAnomalyScore, 0, len(result.AnomalyScores)),
+		Outliers:          make([]ReportOutlier, 0, len(result.Outliers)),
+	}
+
+	for _, cluster := range result.Clusters {
+		members := make([]int, len(cluster.Responses))
+		for i, index := range cluster.Responses {
+			members[i] = loopOf(index)
+		}
+		report.Clusters = append(report.Clusters, ReportCluster{
+			Size:        cluster.Size,
+			Share:       float64(cluster.Size) / float64(result.TotalResponses),
+			MemberLoops: members,
+			MedoidLoop:  loopOf(cluster.Medoid),
+			Medoid:      cluster.Centroid,
+			Silhouette:  cluster.Silhouette,
+		})
+	}
+
+	if result.AbnormalityScore > 0 {
+		report.MostAbnormal = &ReportAbnormal{
+			Loop:     result.MostAbnormal.Loop,
+			Score:    result.AbnormalityScore,
+			Response: getResponseFromEntry(result.MostAbnormal),
+			Diff:     buildReportDiff(result.AbnormalDiff, result.AbnormalReference),
+		}
+	}
+
+	for _, score := range result.AnomalyScores {
+		report.AnomalyScores = append(report.AnomalyScores, ReportAnomalyScore{
+			Loop:           score.Loop,
+			MeanSimilarity: score.MeanSimilarity,
+			Score:          score.Score,
+			Outlier:        score.Outlier,
+		})
+	}
+
+	for _, outlier := range result.Outliers {
+		entry := ReportOutlier{
+			Loop:                    outlier.Entry.Loop,
+			Score:                   outlier.Score,
+			MeanSimilarity:          outlier.MeanSimilarity,
+			Response:                getResponseFromEntry(outlier.Entry),
+			NearestNormalSimilarity: outlier.NearestNormalSimilarity,
+			Diff:                    buildReportDiff(outlier.Diff, outlier.ReferenceLoop),
+		}
+		if outlier.NearestNormal >= 0 {
+			loop := outlier.NearestNormalLoop
+			entry.NearestNormalLoop = &loop
+		}
+		report.Outliers = append(report.Outliers, entry)
+	}
+
+	if summary := result.Assertions; summary != nil {
+		assertions := &ReportAssertions{
+			LoopsEvaluated: summary.LoopsEvaluated,
+			LoopsSkipped:   nonNilInts(summary.LoopsSkipped),
+			LoopsPassed:    summary.LoopsPassed,
+			PassRate:       summary.PassRate,
+			Checks:         summary.Checks,
+			ChecksPassed:   summary.ChecksPassed,
+			CheckPassRate:  summary.CheckPassRate,
+			Failures:       make([]ReportAssertionFailure, 0, len(summary.Failures)),
+			Results:        make([]ReportAssertionResult, 0, len(summary.Results)),
+		}
+		for _, failure := range summary.Failures {
+			assertions.Failures = append(assertions.Failures, ReportAssertionFailure{
+				Assertion: failure.Assertion,
+				Evaluated: failure.Evaluated,
+				Failed:    failure.Failed,
+				Loops:     nonNilInts(failure.Loops),
+			})
+		}
+		for _, res := range summary.Results {
+			assertions.Results = append(assertions.Results, ReportAssertionResult{
+				Loop:      res.Loop,
+				Assertion: res.Assertion,
+				Passed:    res.Passed,
+				Message:   res.Message,
+			})
+		}
+		report.Assertions = assertions
+	}
+
+	if summary := result.Answers; summary != nil {
+		answers := &ReportAnswers{
+			Extractors:     nonNilStrings(summary.Extractors),
+			Extracted:      summary.Extracted,
+			ExtractionRate: summary.ExtractionRate,
+			NoAnswerLoops:  nonNilInts(summary.NoAnswerLoops),
+			Majority:       summary.Majority,
+			MajorityCount:  summary.MajorityCount,
+			Agreement:      summary.Agreement,
+			Entropy:        summary.Entropy,
+			Distribution:   make([]ReportAnswerCount, 0, len(summary.Distribution)),
+			DeviantLoops:   nonNilInts(summary.DeviantLoops),
+			Loops:          make([]ReportLoopAnswer, 0, len(summary.Loops)),
+		}
+		for _, count := range summary.Distribution {
+			answers.Distribution = append(answers.Distribution, ReportAnswerCount(count))
+		}
+		for _, loop := range summary.Loops {
+			answers.Loops = append(answers.Loops, ReportLoopAnswer(loop))
+		}
+		report.Answers = answers
+	}
+
+	if summary := result.Constraints; summary != nil {
+		constraints := &ReportConstraints{
+			LoopsEvaluated: summary.LoopsEvaluated,
+			LoopsCompliant: summary.LoopsCompliant,
+			ComplianceRate: summary.ComplianceRate,
+			Violations:     make([]ReportConstraintViolation, 0, len(summary.Violations)),
+			Loops:          make([]ReportLoopCompliance, 0, len(summary.Loops)),
+		}
+		for _, violation := range summary.Violations {
+			constraints.Violations = append(constraints.Violations, ReportConstraintViolation{
+				Constraint: violation.Constraint,
+				Evaluated:  violation.Evaluated,
+				Violated:   violation.Violated,
+				Loops:      nonNilInts(violation.Loops),
+			})
+		}
+		for _, loop := range summary.Loops {
+			constraints.Loops = append(constraints.Loops, ReportLoopCompliance{
+				Loop:       loop.Loop,
+				Compliant:  loop.Compliant,
+				Violations: nonNilStrings(loop.Violations),
+			})
+		}
+		report.Constraints = constraints
+	}
+
+	if summary := result.Behaviour; summary != nil {
+		behaviour := &ReportBehaviour{
+			Classified:   summary.Classified,
+			AnsweredRate: summary.AnsweredRate,
+			Labels:       make([]ReportBehaviourCount, 0, len(summary.Labels)),
+			Loops:        make([]ReportLoopBehaviour, 0, len(summary.Loops)),
+		}
+		for _, count := range summary.Labels {
+			behaviour.Labels = append(behaviour.Labels, ReportBehaviourCount{
+				Label: string(count.Label),
+				Loops: nonNilInts(count.Loops),
+				Rate:  count.Rate,
+			})
+		}
+		for _, loop := range summary.Loops {
+			behaviour.Loops = append(behaviour.Loops, ReportLoopBehaviour{
+				Loop:  loop.Loop,
+				Label: string(loop.Label),
+				Rule:  loop.Rule,
+			})
+		}
+		report.Behaviour = behaviour
+	}
+
+	if intervals := result.Intervals; intervals != nil {
+		report.Intervals = &ReportIntervals{
+			Iterations:        intervals.Iterations,
+			TargetMargin:      intervals.TargetMargin,
+			AverageSimilarity: buildReportInterval(intervals.AverageSimilarity),
+			MajorityShare:     buildReportInterval(intervals.MajorityShare),
+			PassRate:          buildReportInterval(intervals.PassRate),
+		}
+	}
+
+	if score := result.Score; score != nil {
+		reliability := &ReportReliability{
+			Grade:       score.Grade,
+			Description: score.Description,
+			Composite:   score.Composite,
+			Limiting:    string(score.Limiting),
+			Explanation: score.Explanation,
+			Components:  make([]ReportScoreComponent, 0, len(score.Components)),
+		}
+		for _, c := range score.Components {
+			reliability.Components = append(reliability.Components, ReportScoreComponent{
+				Metric:       string(c.Metric),
+				Value:        c.Value,
+				Score:        c.Score,
+				Weight:       c.Weight,
+				Contribution: c.Contribution,
+			})
+		}
+		report.Reliability = reliability
+	}
+
+	if correlation := result.Latency; correlation != nil {
+		latency := &ReportLatencyCorrelation{
+			Samples:        correlation.Samples,
+			LengthPearson:  correlation.LengthPearson,
+			LengthSpearman: correlation.LengthSpearman,
+			ClusterEffect:  correlation.ClusterEffect,
+			Clusters:       make([]ReportClusterLatency, 0, len(correlation.Clusters)),
+		}
+		for _, cluster := range correlation.Clusters {
+			latency.Clusters = append(latency.Clusters, ReportClusterLatency(cluster))
+		}
+		report.Latency = latency
+	}
+
+	if summary := result.Judgments; summary != nil {
+		judgments := &ReportJudgments{
+			Reference:  summary.Reference,
+			Judged:     summary.Judged,
+			Equivalent: summary.Equivalent,
+			Rate:       summary.Rate,
+			MeanScore:  summary.MeanScore,
+			Results:    make([]ReportJudgeResult, 0, len(summary.Results)),
+		}
+		for _, judged := range summary.Results {
+			judgments.Results = append(judgments.Results, ReportJudgeResult{
+				Loop:       judged.Loop,
+				Equivalent: judged.Verdict.Equivalent,
+				Score:      judged.Verdict.Score,
+				Reason:     judged.Verdict.Reason,
+			})
+		}
+		report.Judgments = judgments
+	}
+
+	if summary := result.Code; summary != nil {
+		code := &ReportCode{
+			LoopsWithCode:   summary.LoopsWithCode,
+			Languages:       make([]ReportLanguageCount, 0, len(summary.Languages)),
+			CodeSimilarity:  summary.CodeSimilarity,
+			ProseSimilarity: summary.ProseSimilarity,
+			GoBlocks:        summary.GoBlocks,
+			GoParsed:        summary.GoParsed,
+			GoFormatted:     summary.GoFormatted,
+			GoVetted:        summary.GoVetted,
+			GoVetClean:      summary.GoVetClean,
+			Loops:           make([]ReportLoopCode, 0, len(summary.Loops)),
+		}
+		for _, language := range summary.Languages {
+			code.Languages = append(code.Languages, ReportLanguageCount(language))
+		}
+		for _, loop := range summary.Loops {
+			reportLoop := ReportLoopCode{Loop: loop.Loop}
+			for _, block := range loop.Blocks {
+				reportLoop.Languages = append(reportLoop.Languages, block.Language)
+			}
+			for _, check := range loop.Go {
+				reportLoop.Go = append(reportLoop.Go, ReportGoCheck(check))
+			}
+			code.Loops = append(code.Loops, reportLoop)
+		}
+		report.Code = code
+	}
+
+	if summary := result.Fields; summary != nil {
+		fields := &ReportFields{
+			JSONLoops:          summary.JSONLoops,
+			NonJSONLoops:       nonNilInts(summary.NonJSONLoops),
+			StructureAgreement: summary.StructureAgreement,
+			FlakyFields:        summary.FlakyFields,
+			MeanConsistency:    summary.MeanConsistency,
+			Fields:             make([]ReportField, 0, len(summary.Fields)),
+		}
+		for _, field := range summary.Fields {
+			reportField := ReportField{
+				Path:           field.Path,
+				Consistency:    field.Consistency,
+				Present:        field.Present,
+				PresenceRate:   field.PresenceRate,
+				MissingLoops:   nonNilInts(field.MissingLoops),
+				Types:          make([]ReportTypeCount, 0, len(field.Types)),
+				TypeStability:  field.TypeStability,
+				Kind:           field.Kind,
+				MajorityValue:  field.MajorityValue,
+				DistinctValues: field.DistinctValues,
+				DeviantLoops:   field.DeviantLoops,
+			}
+			for _, count := range field.Types {
+				reportField.Types = append(reportField.Types, ReportTypeCount(count))
+			}
+			switch field.Kind {
+			case "object":
+			case "array":
+				overlap := field.SetOverlap
+				reportField.SetOverlap = &overlap
+			default:
+				agreement := field.ValueAgreement
+				reportField.ValueAgreement = &agreement
+			}
+			fields.Fields = append(fields.Fields, reportField)
+		}
+		report.Fields = fields
+	}
+
+	if summary := result.Plan; summary != nil {
+		plan := &ReportPlan{
+			LoopsWithPlan:    summary.LoopsWithPlan,
+			LoopsWithoutPlan: nonNilInts(summary.LoopsWithoutPlan),
+			MeanSteps:        summary.MeanSteps,
+			StepStdDev:       summary.StepStdDev,
+			StepCV:           summary.StepCV,
+			MinSteps:         summary.MinSteps,
+			MaxSteps:         summary.MaxSteps,
+			MeanAlignment:    summary.MeanAlignment,
+			AgentAgreement:   summary.AgentAgreement,
+			ReferenceLoop:    summary.ReferenceLoop,
+			ReferenceSteps:   buildReportPlanSteps(summary.ReferenceSteps),
+			AgentAssignments: make([]ReportAgentStepCount, 0, len(summary.AgentAssignments)),
+			UnassignedSteps:  summary.UnassignedSteps,
+			Loops:            make([]ReportLoopPlan, 0, len(summary.Loops)),
+		}
+		for _, agent := range summary.AgentAssignments {
+			plan.AgentAssignments = append(plan.AgentAssignments, ReportAgentStepCount{
+				Agent: agent.Agent,
+				Steps: agent.Calls,
+				Loops: agent.Loops,
+			})
+		}
+		for _, loop := range summary.Loops {
+			plan.Loops = append(plan.Loops, ReportLoopPlan{
+				Loop:           loop.Loop,
+				Steps:          buildReportPlanSteps(loop.Steps),
+				Alignment:      loop.Alignment,
+				AgentAgreement: loop.AgentAgreement,
+				Missing:        nonNilStrings(loop.Missing),
+				Extra:          nonNilStrings(loop.Extra),
+				Reassigned:     nonNilStrings(loop.Reassigned),
+			})
+		}
+		report.Plan = plan
+	}
+
+	return report
+}
+
+// buildReportInterval converts a confidence interval
+func buildReportInterval(ci *ConfidenceInterval) *ReportInterval {
+	if ci == nil {
+		return nil
+	}
+	return &ReportInterval{
+		Estimate:    ci.Estimate,
+		Lower:       ci.Lower,
+		Upper:       ci.Upper,
+		Confidence:  ci.Confidence,
+		Samples:     ci.Samples,
+		LoopsNeeded: ci.LoopsNeeded,
+	}
+}
+
+// responseLoops returns the loop number of each analyzed response
+func responseLoops(result *AnalysisResult) []int {
+	loops := make([]int, len(result.ResponseEntries))
+	for i, entry := range result.ResponseEntries {
+		loops[i] = entry.Loop
+	}
+	return loops
+}
+
+// responseTexts returns the text of each analyzed response
+func responseTexts(result *AnalysisResult) []string {
+	texts := make([]string, len(result.ResponseEntries))
+	for i, entry := range result.ResponseEntries {
+		texts[i] = getResponseFromEntry(entry)
+	}
+	return texts
+}
+
+// nonNilInts keeps empty lists as [] rather than null in JSON output
+func nonNilInts(values []int) []int {
+	if values == nil {
+		return []int{}
+	}
+	return values
+}
+
+// buildReportPlanSteps converts plan steps
+func buildReportPlanSteps(steps []PlanStep) []ReportPlanStep {
+	converted := make([]ReportPlanStep, 0, len(steps))
+	for _, step := range steps {
+		converted = append(converted, ReportPlanStep(step))
+	}
+	return converted
+}
+
+// nonNilStrings keeps empty lists as [] rather than null in JSON output
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// buildReportDiff converts a response diff, returning nil when there is none
+func buildReportDiff(diff *ResponseDiff, referenceLoop int) *ReportDiff {
+	if diff == nil {
+		return nil
+	}
+
+	report := &ReportDiff{
+		ReferenceLoop: referenceLoop,
+		Mode:          string(diff.Mode),
+		Inserted:      diff.Inserted,
+		Deleted:       diff.Deleted,
+		Segments:      make([]ReportDiffSegment, 0, len(diff.Segments)),
+		Text:          diff.Markers(),
+	}
+	for _, segment := range diff.Segments {
+		report.Segments = append(report.Segments, ReportDiffSegment{Op: string(segment.Op), Text: segment.Text, Code: segment.Code})
+	}
+	return report
+}
@@ -0,0 +1,302 @@
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ScoringMetric names a metric that contributes to the reliability score
+type ScoringMetric string
+
+const (
+	MetricSimilarity      ScoringMetric = "similarity"       // average pairwise similarity
+	MetricConsistency     ScoringMetric = "consistency"      // share of responses in the largest cluster
+	MetricNormality       ScoringMetric = "normality"        // 1 - abnormality score
+	MetricPassRate        ScoringMetric = "pass_rate"        // share of loops passing every assertion
+	MetricErrorRate       ScoringMetric = "error_rate"       // scored as 1 - share of loops with errors
+	MetricLatencyVariance ScoringMetric = "latency_variance" // scored as 1 - coefficient of variation (capped at 1)
+	MetricAnsweredRate    ScoringMetric = "answered_rate"    // share of loops without errors classified as answered
+	MetricComplianceRate  ScoringMetric = "compliance_rate"  // share of loops meeting every format constraint
+	MetricAnswerAgreement ScoringMetric = "answer_agreement" // share of extracted answers equal to the majority
+)
+
+// ScoringMetrics lists the metrics a scoring policy can weight
+var ScoringMetrics = []ScoringMetric{
+	MetricSimilarity,
+	MetricConsistency,
+	MetricNormality,
+	MetricPassRate,
+	MetricErrorRate,
+	MetricLatencyVariance,
+	MetricAnsweredRate,
+	MetricComplianceRate,
+	MetricAnswerAgreement,
+}
+
+// GradeBand maps composite scores at or above MinScore to a grade
+type GradeBand struct {
+	Grade       string  `json:"grade"`
+	MinScore    float64 `json:"min_score"`
+	Description string  `json:"description"`
+}
+
+// ScoringPolicy defines how metrics are weighted into a composite score and
+// how composite scores map to grades
+type ScoringPolicy struct {
+	Weights map[ScoringMetric]float64 `json:"weights"`
+	Bands   []GradeBand               `json:"bands"`
+}
+
+// ScoreComponent is one metric's contribution to the composite score
+type ScoreComponent struct {
+	Metric       ScoringMetric
+	Value        float64 // raw metric value
+	Score        float64 // value normalized so 1 is best
+	Weight       float64 // weight after normalizing over available metrics
+	Contribution float64 // Weight * Score
+	Shortfall    float64 // Weight * (1 - Score), the score lost to this metric
+}
+
+// ReliabilityScore is the outcome of applying a scoring policy
+type ReliabilityScore struct {
+	Composite   float64
+	Grade       string
+	Description string
+	Components  []ScoreComponent
+	Limiting    ScoringMetric // metric with the largest shortfall
+	Explanation string
+}
+
+// DefaultScoringPolicy returns the policy used when none is configured
+func DefaultScoringPolicy() ScoringPolicy {
+	return ScoringPolicy{
+		Weights: map[ScoringMetric]float64{
+			MetricSimilarity:      0.35,
+			MetricConsistency:     0.25,
+			MetricNormality:       0.15,
+			MetricPassRate:        0.35,
+			MetricErrorRate:       0.15,
+			MetricLatencyVariance: 0.05,
+			MetricComplianceRate:  0.25,
+			MetricAnswerAgreement: 0.35,
+		},
+		Bands: []GradeBand{
+			{Grade: "EXCELLENT", MinScore: 0.9, Description: "Highly consistent responses"},
+			{Grade: "GOOD", MinScore: 0.75, Description: "Generally consistent with minor variations"},
+			{Grade: "MODERATE", MinScore: 0.55, Description: "Some inconsistency present"},
+			{Grade: "POOR", MinScore: 0.35, Description: "Significant inconsistencies detected"},
+			{Grade: "VERY POOR", MinScore: 0, Description: "Highly unreliable responses"},
+		},
+	}
+}
+
+// LoadScoringPolicy reads a scoring policy file. Missing weights or bands
+// fall back to the defaults.
+func LoadScoringPolicy(filename string) (ScoringPolicy, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return ScoringPolicy{}, fmt.Errorf("failed to read scoring policy: %w", err)
+	}
+
+	var policy ScoringPolicy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return ScoringPolicy{}, fmt.Errorf("failed to parse scoring policy: %w", err)
+	}
+
+	defaults := DefaultScoringPolicy()
+	if len(policy.Weights) == 0 {
+		policy.Weights = defaults.Weights
+	}
+	if len(policy.Bands) == 0 {
+		policy.Bands = defaults.Bands
+	}
+
+	if err := policy.Validate(); err != nil {
+		return ScoringPolicy{}, err
+	}
+	return policy, nil
+}
+
+// Validate checks metric names, weights and grade bands
+func (p ScoringPolicy) Validate() error {
+	total := 0.0
+	for metric, weight := range p.Weights {
+		known := false
+		for _, m := range ScoringMetrics {
+			if metric == m {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("scoring policy: unknown metric %q", metric)
+		}
+		if weight < 0 {
+			return fmt.Errorf("scoring policy: metric %q has negative weight", metric)
+		}
+		total += weight
+	}
+	if total == 0 {
+		return fmt.Errorf("scoring policy: at least one metric needs a positive weight")
+	}
+
+	for _, band := range p.Bands {
+		if band.Grade == "" {
+			return fmt.Errorf("scoring policy: grade band with min_score %g has no grade", band.MinScore)
+		}
+	}
+	return nil
+}
+
+// grade returns the band for a composite score, checking the highest bands first
+func (p ScoringPolicy) grade(composite float64) GradeBand {
+	bands := append([]GradeBand(nil), p.Bands...)
+	sort.SliceStable(bands, func(i, j int) bool {
+		return bands[i].MinScore > bands[j].MinScore
+	})
+
+	for _, band := range bands {
+		if composite >= band.MinScore-1e-9 {
+			return band
+		}
+	}
+	if len(bands) > 0 {
+		return bands[len(bands)-1]
+	}
+	return GradeBand{Grade: "UNGRADED"}
+}
+
+// ScoreReliability computes the composite reliability score of an agent's
+// analysis. Metrics that are unavailable (e.g. pass rate without assertions)
+// are left out and the remaining weights renormalized.
+func ScoreReliability(result *AnalysisResult, policy ScoringPolicy) *ReliabilityScore {
+	if result == nil || result.TotalResponses == 0 {
+		return nil
+	}
+
+	values := scoringMetricValues(result)
+
+	var components []ScoreComponent
+	totalWeight := 0.0
+	for _, metric := range ScoringMetrics {
+		weight := policy.Weights[metric]
+		value, available := values[metric]
+		if weight <= 0 || !available {
+			continue
+		}
+		components = append(components, ScoreComponent{
+			Metric: metric,
+			Value:  value,
+			Score:  normalizeMetric(metric, value),
+			Weight: weight,
+		})
+		totalWeight += weight
+	}
+	if totalWeight == 0 {
+		return nil
+	}
+
+	score := &ReliabilityScore{}
+	largestShortfall := -1.0
+	for i := range components {
+		c := &components[i]
+		c.Weight /= totalWeight
+		c.Contribution = c.Weight * c.Score
+		c.Shortfall = c.Weight * (1 - c.Score)
+		score.Composite += c.Contribution
+
+		if c.Shortfall > largestShortfall {
+			largestShortfall = c.Shortfall
+			score.Limiting = c.Metric
+		}
+	}
+	score.Components = components
+
+	band := policy.grade(score.Composite)
+	score.Grade = band.Grade
+	score.Description = band.Description
+	score.Explanation = explainScore(score)
+
+	return score
+}
+
+// scoringMetricValues gathers the raw value of every available metric
+func scoringMetricValues(result *AnalysisResult) map[ScoringMetric]float64 {
+	values := map[ScoringMetric]float64{
+		MetricSimilarity:  result.AverageSimilarity,
+		MetricConsistency: float64(result.MostCommonCount) / float64(result.TotalResponses),
+		MetricNormality:   1 - result.AbnormalityScore,
+	}
+
+	if result.Assertions != nil && result.Assertions.LoopsEvaluated > 0 {
+		values[MetricPassRate] = result.Assertions.PassRate
+	}
+
+	if len(result.ResponseEntries) > 0 {
+		errors := 0
+		for _, entry := range result.ResponseEntries {
+			if strings.TrimSpace(entry.Errors) != "" {
+				errors++
+			}
+		}
+		values[MetricErrorRate] = float64(errors) / float64(len(result.ResponseEntries))
+	}
+
+	if result.Answers != nil && result.Answers.Extracted > 0 {
+		values[MetricAnswerAgreement] = result.Answers.Agreement
+	}
+
+	if result.Constraints != nil {
+		values[MetricComplianceRate] = result.Constraints.ComplianceRate
+	}
+
+	if rate, ok := result.Behaviour.completedAnsweredRate(); ok {
+		values[MetricAnsweredRate] = rate
+	}
+
+	if cv, ok := latencyCoefficientOfVariation(result.ResponseEntries); ok {
+		values[MetricLatencyVariance] = cv
+	}
+
+	return values
+}
+
+// normalizeMetric converts a raw metric value to a 0-1 score where 1 is best
+func normalizeMetric(metric ScoringMetric, value float64) float64 {
+	switch metric {
+	case MetricErrorRate:
+		value = 1 - value
+	case MetricLatencyVariance:
+		value = 1 - math.Min(value, 1)
+	}
+	return math.Max(0, math.Min(1, value))
+}
+
+// latencyCoefficientOfVariation returns the standard deviation of execution
+// times divided by their mean, requiring at least two timed entries
+func latencyCoefficientOfVariation(entries []LogEntry) (float64, bool) {
+	stats := ComputeLatencyStats(entries)
+	if stats == nil || stats.Samples < 2 || stats.Mean == 0 {
+		return 0, false
+	}
+	return stats.CV, true
+}
+
+// explainScore describes which metric pulled the composite score down
+func explainScore(score *ReliabilityScore) string {
+	for _, c := range score.Components {
+		if c.Metric != score.Limiting {
+			continue
+		}
+		if c.Shortfall < 1e-9 {
+			return "All metrics at their best values"
+		}
+		return fmt.Sprintf("%s (%.3f) cost %.3f of the composite score, more than any other metric",
+			c.Metric, c.Value, c.Shortfall)
+	}
+	return ""
+}
@@ -1,7 +1,6 @@
 package analysis
 
 import (
-	"sort"
 	"strings"
 	"unicode"
 )
@@ -15,19 +14,30 @@ func LevenshteinDistance(a, b string) int {
 		return len(a)
 	}
 
-	matrix := make([][]int, len(a)+1)
+	matrix := editMatrix(len(a), len(b), func(i, j int) bool {
+		return a[i] == b[j]
+	})
+	return matrix[len(a)][len(b)]
+}
+
+// editMatrix fills the edit distance table between two sequences of length n
+// and m, where equal reports whether element i of the first matches element j
+// of the second. Cell [i][j] holds the distance between the prefixes of
+// length i and j.
+func editMatrix(n, m int, equal func(i, j int) bool) [][]int {
+	matrix := make([][]int, n+1)
 	for i := range matrix {
-		matrix[i] = make([]int, len(b)+1)
+		matrix[i] = make([]int, m+1)
 		matrix[i][0] = i
 	}
-	for j := 0; j <= len(b); j++ {
+	for j := 0; j <= m; j++ {
 		matrix[0][j] = j
 	}
 
-	for i := 1; i <= len(a); i++ {
-		for j := 1; j <= len(b); j++ {
+	for i := 1; i <= n; i++ {
+		for j := 1; j <= m; j++ {
 			cost := 0
-			if a[i-1] != b[j-1] {
+			if !equal(i-1, j-1) {
 				cost = 1
 			}
 			matrix[i][j] = min(
@@ -37,7 +47,7 @@ func LevenshteinDistance(a, b string) int {
 		}
 	}
 
-	return matrix[len(a)][len(b)]
+	return matrix
 }
 
 // LevenshteinSimilarity converts distance to similarity score (0-1)
@@ -139,6 +149,7 @@ func FindMostAbnormal(entries []LogEntry, matrix [][]float64) (LogEntry, float64
 	}
 
 	minAvgSimilarity := 1.0
+	maxAvgSimilarity := 0.0
 	mostAbnormalIndex := 0
 
 	for i := range entries {
@@ -158,9 +169,17 @@ func FindMostAbnormal(entries []LogEntry, matrix [][]float64) (LogEntry, float64
 				minAvgSimilarity = avgSimilarity
 				mostAbnormalIndex = i
 			}
+			if avgSimilarity > maxAvgSimilarity {
+				maxAvgSimilarity = avgSimilarity
+			}
 		}
 	}
 
+	// When every response is equally far from the others none stands out
+	if len(entries) < 2 || maxAvgSimilarity-minAvgSimilarity < 1e-9 {
+		return entries[mostAbnormalIndex], 0.0
+	}
+
 	abnormalityScore := 1.0 - minAvgSimilarity
 	return entries[mostAbnormalIndex], abnormalityScore
 }
@@ -186,7 +205,9 @@ func tokenize(text string) []string {
 	return words
 }
 
-// ClusterResponses groups similar responses together
+// ClusterResponses groups similar responses together in a single greedy pass.
+// Membership depends on input order; see ClusterResponsesWith for
+// order-independent methods.
 func ClusterResponses(responses []string, matrix [][]float64, threshold float64) []ResponseCluster {
 	n := len(responses)
 	if n == 0 {
@@ -194,38 +215,28 @@ func ClusterResponses(responses []string, matrix [][]float64, threshold float64)
 	}
 
 	visited := make([]bool, n)
-	var clusters []ResponseCluster
+	var groups [][]int
 
 	for i := 0; i < n; i++ {
 		if visited[i] {
 			continue
 		}
 
-		cluster := ResponseCluster{
-			Responses: []int{i},
-			Centroid:  responses[i],
-			Size:      1,
-		}
+		group := []int{i}
 		visited[i] = true
 
 		// Find all responses similar to this one
 		for j := i + 1; j < n; j++ {
 			if !visited[j] && matrix[i][j] >= threshold {
-				cluster.Responses = append(cluster.Responses, j)
-				cluster.Size++
+				group = append(group, j)
 				visited[j] = true
 			}
 		}
 
-		clusters = append(clusters, cluster)
+		groups = append(groups, group)
 	}
 
-	// Sort clusters by size (largest first)
-	sort.Slice(clusters, func(i, j int) bool {
-		return clusters[i].Size > clusters[j].Size
-	})
-
-	return clusters
+	return buildClusters(responses, matrix, groups)
 }
 
 func min(a, b int) int {
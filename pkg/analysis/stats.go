@@ -0,0 +1,172 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+)
+
+// MannWhitneyU performs a two-sided Mann-Whitney U test using the normal
+// approximation with tie correction, returning U for the first sample and
+// the p-value. It makes no assumption about the shape of the distributions.
+func MannWhitneyU(a, b []float64) (float64, float64) {
+	n1, n2 := len(a), len(b)
+	if n1 == 0 || n2 == 0 {
+		return 0, 1
+	}
+
+	type observation struct {
+		value float64
+		first bool
+	}
+	combined := make([]observation, 0, n1+n2)
+	for _, v := range a {
+		combined = append(combined, observation{v, true})
+	}
+	for _, v := range b {
+		combined = append(combined, observation{v, false})
+	}
+	sort.SliceStable(combined, func(i, j int) bool {
+		return combined[i].value < combined[j].value
+	})
+
+	// Assign average ranks to ties and accumulate the tie correction
+	rankSum := 0.0
+	tieTerm := 0.0
+	for i := 0; i < len(combined); {
+		j := i
+		for j < len(combined) && combined[j].value == combined[i].value {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if combined[k].first {
+				rankSum += rank
+			}
+		}
+		t := float64(j - i)
+		tieTerm += t*t*t - t
+		i = j
+	}
+
+	u := rankSum - float64(n1*(n1+1))/2
+	mean := float64(n1*n2) / 2
+	n := float64(n1 + n2)
+	variance := float64(n1*n2) / 12 * ((n + 1) - tieTerm/(n*(n-1)))
+	if variance <= 0 {
+		return u, 1
+	}
+
+	// Continuity correction towards the mean
+	diff := math.Abs(u-mean) - 0.5
+	if diff < 0 {
+		diff = 0
+	}
+	z := diff / math.Sqrt(variance)
+	return u, 2 * (1 - normalCDF(z))
+}
+
+// TwoProportionZTest performs a two-sided test that two proportions are equal
+func TwoProportionZTest(successes1, n1, successes2, n2 int) float64 {
+	if n1 == 0 || n2 == 0 {
+		return 1
+	}
+	p1 := float64(successes1) / float64(n1)
+	p2 := float64(successes2) / float64(n2)
+	pooled := float64(successes1+successes2) / float64(n1+n2)
+
+	se := math.Sqrt(pooled * (1 - pooled) * (1/float64(n1) + 1/float64(n2)))
+	if se == 0 {
+		return 1
+	}
+	z := math.Abs(p1-p2) / se
+	return 2 * (1 - normalCDF(z))
+}
+
+// normalCDF is the standard normal cumulative distribution function
+func normalCDF(z float64) float64 {
+	return 0.5 * math.Erfc(-z/math.Sqrt2)
+}
+
+// PearsonCorrelation returns the linear correlation of two equal-length
+// samples, or 0 when either has no variance
+func PearsonCorrelation(x, y []float64) float64 {
+	n := len(x)
+	if n < 2 || n != len(y) {
+		return 0
+	}
+
+	meanX, meanY := 0.0, 0.0
+	for i := range x {
+		meanX += x[i]
+		meanY += y[i]
+	}
+	meanX /= float64(n)
+	meanY /= float64(n)
+
+	covariance, varianceX, varianceY := 0.0, 0.0, 0.0
+	for i := range x {
+		dx, dy := x[i]-meanX, y[i]-meanY
+		covariance += dx * dy
+		varianceX += dx * dx
+		varianceY += dy * dy
+	}
+	if varianceX == 0 || varianceY == 0 {
+		return 0
+	}
+	return covariance / math.Sqrt(varianceX*varianceY)
+}
+
+// SpearmanCorrelation returns the rank correlation of two equal-length
+// samples, which is robust to outliers and any monotonic relationship
+func SpearmanCorrelation(x, y []float64) float64 {
+	if len(x) < 2 || len(x) != len(y) {
+		return 0
+	}
+	return PearsonCorrelation(ranks(x), ranks(y))
+}
+
+// ranks assigns 1-based ranks, averaging the ranks of tied values
+func ranks(values []float64) []float64 {
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return values[order[a]] < values[order[b]]
+	})
+
+	result := make([]float64, len(values))
+	for i := 0; i < len(order); {
+		j := i
+		for j < len(order) && values[order[j]] == values[order[i]] {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			result[order[k]] = rank
+		}
+		i = j
+	}
+	return result
+}
+
+// meanStdDev returns the mean and sample standard deviation of values
+func meanStdDev(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	mean := 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+
+	if len(values) < 2 {
+		return mean, 0
+	}
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(variance / float64(len(values)-1))
+}
@@ -0,0 +1,123 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMannWhitneyU(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []float64
+		u    float64
+		p    float64
+	}{
+		{
+			// Rank sum of a is 1+2+3 = 6, so U = 6 - 3*4/2 = 0. Mean 4.5,
+			// variance 3*3/12 * 7 = 5.25, z = (4.5 - 0.5) / sqrt(5.25)
+			name: "fully separated",
+			a:    []float64{1, 2, 3},
+			b:    []float64{4, 5, 6},
+			u:    0,
+			p:    0.0808556,
+		},
+		{
+			// The three 2s share rank 3, so a's rank sum is 1+3+3 = 7 and
+			// U = 1. The tie term 3^3-3 = 24 lowers the variance to
+			// 0.75 * (7 - 24/30) = 4.65, z = (3.5 - 0.5) / sqrt(4.65)
+			name: "ties",
+			a:    []float64{1, 2, 2},
+			b:    []float64{2, 3, 4},
+			u:    1,
+			p:    0.1641597,
+		},
+		{
+			name: "identical samples",
+			a:    []float64{5, 5},
+			b:    []float64{5, 5},
+			u:    2,
+			p:    1,
+		},
+		{
+			name: "empty sample",
+			a:    nil,
+			b:    []float64{1},
+			u:    0,
+			p:    1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, p := MannWhitneyU(tt.a, tt.b)
+			if u != tt.u {
+				t.Errorf("U = %v, want %v", u, tt.u)
+			}
+			if math.Abs(p-tt.p) > 1e-6 {
+				t.Errorf("p = %.7f, want %.7f", p, tt.p)
+			}
+		})
+	}
+}
+
+func TestTwoProportionZTest(t *testing.T) {
+	tests := []struct {
+		name           string
+		s1, n1, s2, n2 int
+		want           float64
+	}{
+		// Pooled 0.6, se = sqrt(0.24 * 0.2), z = 0.4 / se = 1.8257
+		{"8/10 against 4/10", 8, 10, 4, 10, 0.0678892},
+		{"equal proportions", 5, 10, 10, 20, 1},
+		{"no variance", 10, 10, 10, 10, 1},
+		{"empty sample", 0, 0, 3, 10, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TwoProportionZTest(tt.s1, tt.n1, tt.s2, tt.n2); math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("p = %.7f, want %.7f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCorrelation(t *testing.T) {
+	x := []float64{1, 2, 3, 4, 5}
+	tests := []struct {
+		name     string
+		x, y     []float64
+		pearson  float64
+		spearman float64
+	}{
+		{"linear", x, []float64{2, 4, 6, 8, 10}, 1, 1},
+		{"reversed", x, []float64{5, 4, 3, 2, 1}, -1, -1},
+		// Deviations (-2,-1,0,1,2) and (-2,0,-1,2,1): 8 / sqrt(10 * 10)
+		{"partial", x, []float64{1, 3, 2, 5, 4}, 0.8, 0.8},
+		// x^3 is monotonic, so the ranks correlate perfectly
+		{"monotonic", x, []float64{1, 8, 27, 64, 125}, 0.9431175, 1},
+		{"no variance", x, []float64{3, 3, 3, 3, 3}, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PearsonCorrelation(tt.x, tt.y); math.Abs(got-tt.pearson) > 1e-6 {
+				t.Errorf("Pearson = %.7f, want %.7f", got, tt.pearson)
+			}
+			if got := SpearmanCorrelation(tt.x, tt.y); math.Abs(got-tt.spearman) > 1e-6 {
+				t.Errorf("Spearman = %.7f, want %.7f", got, tt.spearman)
+			}
+		})
+	}
+}
+
+func TestRanks(t *testing.T) {
+	got := ranks([]float64{20, 10, 30, 20})
+	want := []float64{2.5, 1, 4, 2.5}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("ranks = %v, want %v", got, want)
+			break
+		}
+	}
+}
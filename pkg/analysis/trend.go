@@ -0,0 +1,413 @@
+package analysis
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TrendSchemaVersion is the version of the JSON trend schema, versioned
+// separately from the single-run report
+const TrendSchemaVersion = "1"
+
+// TrendMetrics are the per-agent metrics tracked across runs
+type TrendMetrics struct {
+	AverageSimilarity float64  `json:"average_similarity"`
+	MajorityShare     float64  `json:"majority_share"`
+	Clusters          int      `json:"clusters"`
+	Composite         float64  `json:"composite_score"`
+	Grade             string   `json:"grade"`
+	PassRate          *float64 `json:"pass_rate,omitempty"`
+}
+
+// TrendPoint is the analysis of a single log file in a trend
+type TrendPoint struct {
+	File          string        `json:"file"`
+	Time          time.Time     `json:"time"`
+	Entries       int           `json:"entries"`
+	ErrorRate     float64       `json:"error_rate"`
+	MedianLatency float64       `json:"median_latency_seconds"`
+	Main          *TrendMetrics `json:"main_agent"`
+	Sub           *TrendMetrics `json:"sub_agent"`
+}
+
+// TrendGroup is a time series of runs sharing an agent and template
+type TrendGroup struct {
+	Key      string       `json:"key"`
+	Agent    string       `json:"agent"`
+	Template string       `json:"template"`
+	Points   []TrendPoint `json:"points"`
+}
+
+// TrendSkip is a log file left out of a trend because it could not be analyzed
+type TrendSkip struct {
+	File  string `json:"file"`
+	Error string `json:"error"`
+}
+
+// Trend is the analysis of many runs, grouped by agent and template
+type Trend struct {
+	Groups  []TrendGroup
+	Skipped []TrendSkip
+}
+
+var logTimestampSuffix = regexp.MustCompile(`^(.*)_(\d{9,})$`)
+
+// ExpandLogPaths resolves files, directories (their *.log and *.log.gz
+// files) and glob patterns into a sorted list of log files
+func ExpandLogPaths(args []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var files []string
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			files = append(files, path)
+		}
+	}
+
+	for _, arg := range args {
+		if info, err := os.Stat(arg); err == nil {
+			if !info.IsDir() {
+				add(arg)
+				continue
+			}
+			for _, pattern := range []string{"*.log", "*.log.gz"} {
+				matches, err := filepath.Glob(filepath.Join(arg, pattern))
+				if err != nil {
+					return nil, err
+				}
+				for _, match := range matches {
+					add(match)
+				}
+			}
+			continue
+		}
+
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", arg, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no log files match %q", arg)
+		}
+		for _, match := range matches {
+			if info, err := os.Stat(match); err == nil && !info.IsDir() {
+				add(match)
+			}
+		}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// AnalyzeTrend analyzes every log file and groups the results by agent and
+// template, ordered by run time. Files that cannot be analyzed are skipped
+// and recorded; it fails only when none can be.
+func AnalyzeTrend(files []string, opts AnalysisOptions) (*Trend, error) {
+	trend := &Trend{}
+	groups := make(map[string]*TrendGroup)
+
+	for _, file := range files {
+		result, err := AnalyzeLogFileWithOptions(file, opts)
+		if err != nil {
+			trend.Skipped = append(trend.Skipped, TrendSkip{File: file, Error: err.Error()})
+			continue
+		}
+
+		agent, template := result.Metadata.Agent, result.Metadata.Template
+		key := agent
+		if template != "" && template != "default" {
+			key += " / " + filepath.Base(template)
+		}
+		if agent == "" {
+			// Logs without a header are grouped by their base filename
+			key = filenameGroup(file)
+		}
+
+		group, ok := groups[key]
+		if !ok {
+			group = &TrendGroup{Key: key, Agent: agent, Template: template}
+			groups[key] = group
+		}
+		group.Points = append(group.Points, buildTrendPoint(file, result))
+	}
+
+	if len(groups) == 0 && len(trend.Skipped) > 0 {
+		return nil, fmt.Errorf("no log file could be analyzed, first error: %s: %s", trend.Skipped[0].File, trend.Skipped[0].Error)
+	}
+
+	sorted := make([]TrendGroup, 0, len(groups))
+	for _, group := range groups {
+		sort.SliceStable(group.Points, func(i, j int) bool {
+			return group.Points[i].Time.Before(group.Points[j].Time)
+		})
+		sorted = append(sorted, *group)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Key < sorted[j].Key
+	})
+
+	trend.Groups = sorted
+
+	return trend, nil
+}
+
+// buildTrendPoint extracts the tracked metrics of one run
+func buildTrendPoint(file string, result *DualAgentAnalysisResult) TrendPoint {
+	point := TrendPoint{
+		File:    file,
+		Time:    runTime(file, result),
+		Entries: result.TotalEntries,
+		Main:    buildTrendMetrics(result.MainAgentAnalysis),
+		Sub:     buildTrendMetrics(result.SubAgentAnalysis),
+	}
+
+	errors, total := countErrors(result.Entries)
+	if total > 0 {
+		point.ErrorRate = float64(errors) / float64(total)
+	}
+	point.MedianLatency = median(executionSeconds(result.Entries))
+
+	return point
+}
+
+// buildTrendMetrics extracts one agent's tracked metrics
+func buildTrendMetrics(result *AnalysisResult) *TrendMetrics {
+	if result == nil {
+		return nil
+	}
+
+	metrics := &TrendMetrics{
+		AverageSimilarity: result.AverageSimilarity,
+		MajorityShare:     float64(result.MostCommonCount) / float64(result.TotalResponses),
+		Clusters:          len(result.Clusters),
+	}
+	if result.Score != nil {
+		metrics.Composite = result.Score.Composite
+		metrics.Grade = result.Score.Grade
+	}
+	if result.Assertions != nil {
+		passRate := result.Assertions.PassRate
+		metrics.PassRate = &passRate
+	}
+	return metrics
+}
+
+// runTime determines when a run happened from its header, the unix
+// timestamp in its filename, its first entry, or the file's modification time
+func runTime(file string, result *DualAgentAnalysisResult) time.Time {
+	if !result.Metadata.Started.IsZero() {
+		return result.Metadata.Started
+	}
+
+	if matches := logTimestampSuffix.FindStringSubmatch(logBaseName(file)); matches != nil {
+		if unix, err := strconv.ParseInt(matches[2], 10, 64); err == nil {
+			return time.Unix(unix, 0).UTC()
+		}
+	}
+
+	if len(result.Entries) > 0 {
+		return result.Entries[0].Timestamp
+	}
+
+	if info, err := os.Stat(file); err == nil {
+		return info.ModTime().UTC()
+	}
+	return time.Time{}
+}
+
+// filenameGroup strips the unix timestamp suffix from a log filename
+func filenameGroup(file string) string {
+	base := logBaseName(file)
+	if matches := logTimestampSuffix.FindStringSubmatch(base); matches != nil {
+		return matches[1]
+	}
+	return base
+}
+
+// logBaseName strips the directory and the .log or .log.gz extension
+func logBaseName(file string) string {
+	base := strings.TrimSuffix(filepath.Base(file), ".gz")
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// WriteTrendJSON writes a trend as indented JSON
+func WriteTrendJSON(w io.Writer, trend *Trend) error {
+	skipped := trend.Skipped
+	if skipped == nil {
+		skipped = []TrendSkip{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(struct {
+		SchemaVersion string       `json:"schema_version"`
+		Groups        []TrendGroup `json:"groups"`
+		Skipped       []TrendSkip  `json:"skipped"`
+	}{TrendSchemaVersion, trend.Groups, skipped})
+}
+
+// WriteTrendCSV writes one row per run. Skipped files have no row.
+func WriteTrendCSV(w io.Writer, trend *Trend) error {
+	writer := csv.NewWriter(w)
+	header := []string{
+		"group", "agent", "template", "file", "time", "entries", "error_rate", "median_latency_seconds",
+		"main_similarity", "main_majority_share", "main_clusters", "main_score", "main_grade", "main_pass_rate",
+		"sub_similarity", "sub_majority_share", "sub_clusters", "sub_score", "sub_grade", "sub_pass_rate",
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, group := range trend.Groups {
+		for _, point := range group.Points {
+			row := []string{
+				group.Key, group.Agent, group.Template, point.File,
+				point.Time.Format(time.RFC3339),
+				strconv.Itoa(point.Entries),
+				formatFloat(point.ErrorRate),
+				formatFloat(point.MedianLatency),
+			}
+			row = append(row, trendMetricColumns(point.Main)...)
+			row = append(row, trendMetricColumns(point.Sub)...)
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// trendMetricColumns formats an agent's metrics as CSV columns
+func trendMetricColumns(metrics *TrendMetrics) []string {
+	if metrics == nil {
+		return []string{"", "", "", "", "", ""}
+	}
+	passRate := ""
+	if metrics.PassRate != nil {
+		passRate = formatFloat(*metrics.PassRate)
+	}
+	return []string{
+		formatFloat(metrics.AverageSimilarity),
+		formatFloat(metrics.MajorityShare),
+		strconv.Itoa(metrics.Clusters),
+		formatFloat(metrics.Composite),
+		metrics.Grade,
+		passRate,
+	}
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', 4, 64)
+}
+
+// PrintTrendSummary prints each group's metrics over time with sparklines,
+// followed by the files that were skipped
+func PrintTrendSummary(trend *Trend) {
+	fmt.Println("=== RELIABILITY TREND ===")
+	for _, group := range trend.Groups {
+		points := group.Points
+		fmt.Printf("\n--- %s (%d runs, %s to %s) ---\n", group.Key, len(points),
+			points[0].Time.Format("2006-01-02"), points[len(points)-1].Time.Format("2006-01-02"))
+
+		printTrendLine("Sub similarity", points, func(p TrendPoint) (float64, bool) {
+			if p.Sub == nil {
+				return 0, false
+			}
+			return p.Sub.AverageSimilarity, true
+		})
+		printTrendLine("Sub majority share", points, func(p TrendPoint) (float64, bool) {
+			if p.Sub == nil {
+				return 0, false
+			}
+			return p.Sub.MajorityShare, true
+		})
+		printTrendLine("Sub score", points, func(p TrendPoint) (float64, bool) {
+			if p.Sub == nil {
+				return 0, false
+			}
+			return p.Sub.Composite, true
+		})
+		printTrendLine("Sub pass rate", points, func(p TrendPoint) (float64, bool) {
+			if p.Sub == nil || p.Sub.PassRate == nil {
+				return 0, false
+			}
+			return *p.Sub.PassRate, true
+		})
+		printTrendLine("Main similarity", points, func(p TrendPoint) (float64, bool) {
+			if p.Main == nil {
+				return 0, false
+			}
+			return p.Main.AverageSimilarity, true
+		})
+		printTrendLine("Error rate", points, func(p TrendPoint) (float64, bool) {
+			return p.ErrorRate, true
+		})
+		printTrendLine("Median latency (s)", points, func(p TrendPoint) (float64, bool) {
+			return p.MedianLatency, p.MedianLatency > 0
+		})
+
+		if last := points[len(points)-1]; last.Sub != nil && last.Sub.Grade != "" {
+			fmt.Printf("Latest sub agent grade: %s\n", last.Sub.Grade)
+		}
+	}
+
+	if len(trend.Skipped) > 0 {
+		fmt.Printf("\n--- SKIPPED (%d files) ---\n", len(trend.Skipped))
+		for _, skip := range trend.Skipped {
+			fmt.Printf("%s: %s\n", skip.File, skip.Error)
+		}
+	}
+}
+
+// printTrendLine prints a sparkline with the first and latest values
+func printTrendLine(name string, points []TrendPoint, value func(TrendPoint) (float64, bool)) {
+	var values []float64
+	for _, p := range points {
+		if v, ok := value(p); ok {
+			values = append(values, v)
+		}
+	}
+	if len(values) == 0 {
+		return
+	}
+
+	first, last := values[0], values[len(values)-1]
+	fmt.Printf("%-20s %s  %.3f -> %.3f (%+.3f)\n", name, Sparkline(values), first, last, last-first)
+}
+
+// Sparkline renders values as a line of block characters scaled between
+// their minimum and maximum
+func Sparkline(values []float64) string {
+	blocks := []rune("▁▂▃▄▅▆▇█")
+	if len(values) == 0 {
+		return ""
+	}
+
+	low, high := values[0], values[0]
+	for _, v := range values {
+		low = math.Min(low, v)
+		high = math.Max(high, v)
+	}
+
+	var line strings.Builder
+	for _, v := range values {
+		level := len(blocks) / 2
+		if high > low {
+			level = int((v - low) / (high - low) * float64(len(blocks)-1))
+		}
+		line.WriteRune(blocks[level])
+	}
+	return line.String()
+}
@@ -11,6 +11,16 @@ type LogEntry struct {
 	RawResponse       string // Original full response
 	Errors            string
 	ExecutionTime     time.Duration
+	ToolCalls         []ToolCall // tool calls from the CLI's structured output
+	ToolTrace         bool       // the log recorded a tool trace for this loop
+}
+
+// LogMetadata is the run header written at the top of a log file
+type LogMetadata struct {
+	Agent    string
+	Template string
+	Loops    int
+	Started  time.Time
 }
 
 type SimilarityScore struct {
@@ -23,26 +33,76 @@ type SimilarityScore struct {
 
 type AnalysisResult struct {
 	TotalResponses    int
+	Metric            string // similarity metric used for the matrix
 	AverageSimilarity float64
 	MostCommonPattern string
 	MostCommonCount   int
 	MostAbnormal      LogEntry
 	AbnormalityScore  float64
+	AbnormalDiff      *ResponseDiff // most abnormal response against its reference medoid
+	AbnormalReference int           // loop of that medoid
 	SimilarityMatrix  [][]float64
 	Clusters          []ResponseCluster
+	ClusterMethod     ClusterMethod
+	Silhouette        float64    // average silhouette of the clustering
+	ResponseEntries   []LogEntry // entry for each analyzed response, by index
+	AnomalyScores     []AnomalyScore
+	Outliers          []Outlier
+	OutlierMethod     OutlierMethod
+	OutlierThreshold  float64
+	Assertions        *AssertionSummary // nil when no assertions target this agent
+	Behaviour         *BehaviourSummary
+	Constraints       *ConstraintSummary // nil when no format constraint targets this agent
+	Score             *ReliabilityScore
+	Intervals         *ReliabilityIntervals
+	Latency           *LatencyCorrelation // nil when fewer than three responses were timed
+	Judgments         *JudgeSummary       // nil unless a judge and reference answer are configured
+	Code              *CodeSummary        // nil when no response contains a code block
+	Fields            *FieldSummary       // nil unless most responses contain JSON
+	Answers           *AnswerSummary      // nil unless answer extractors are configured
+	Plan              *PlanSummary        // nil unless plan analysis is enabled and two responses contain a plan
 }
 
 type DualAgentAnalysisResult struct {
+	Metadata           LogMetadata
+	Warnings           []ParseWarning
 	TotalEntries       int
 	MainAgentAnalysis  *AnalysisResult
 	SubAgentAnalysis   *AnalysisResult
 	MainAgentResponses []string
 	SubAgentResponses  []string
 	Entries            []LogEntry
+	Assertions         *AssertionReport // nil when no assertions were given
+	Latency            *LatencyStats    // nil when no execution times were recorded
+	Errors             *ErrorSummary
+	Delegation         *DelegationSummary // nil when the log has no tool traces
+	Relay              *RelaySummary      // nil when no response reports the relayed message
 }
 
 type ResponseCluster struct {
-	Responses []int // indices of responses in this cluster
-	Centroid  string
-	Size      int
+	Responses  []int  // indices of responses in this cluster
+	Centroid   string // text of the medoid response
+	Size       int
+	Medoid     int     // index of the member most similar to the rest
+	Silhouette float64 // average silhouette of the members
+}
+
+type AnomalyScore struct {
+	Index          int // index of the response
+	Loop           int
+	MeanSimilarity float64 // mean similarity to the other responses
+	Score          float64 // robust z-score or local outlier factor
+	Outlier        bool
+}
+
+type Outlier struct {
+	Entry                   LogEntry
+	Index                   int
+	Score                   float64
+	MeanSimilarity          float64
+	NearestNormal           int // index of the most similar non-outlier, -1 if none
+	NearestNormalLoop       int
+	NearestNormalSimilarity float64
+	ReferenceLoop           int           // loop of the medoid the response is diffed against
+	Diff                    *ResponseDiff // nil if there is no medoid to compare with
 }
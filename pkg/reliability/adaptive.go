@@ -0,0 +1,152 @@
+package reliability
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"agent-reliability-tests/pkg/analysis"
+)
+
+// Adaptive mode defaults
+const (
+	DefaultMinLoops     = 10
+	DefaultTargetMargin = 0.1
+	DefaultCheckEvery   = 1
+)
+
+// loopOutcome is the result of a single loop reported back to the scheduler
+type loopOutcome struct {
+	loopNum  int
+	response string
+	err      error
+}
+
+// runLoopsAdaptive executes loops with a worker queue, analyzing completed
+// responses in-process and stopping dispatch once the confidence interval on
+// the majority-cluster share is narrower than the target margin
+func runLoopsAdaptive(config TestConfig, outputFile string, startTime time.Time, workerCount int) (*TestResult, error) {
+	maxLoops := config.Loops
+	minLoops := config.MinLoops
+	if minLoops <= 0 {
+		minLoops = DefaultMinLoops
+	}
+	if minLoops > maxLoops {
+		fmt.Printf("Minimum of %d loops exceeds the maximum of %d, checking convergence after %d loops\n", minLoops, maxLoops, maxLoops)
+		minLoops = maxLoops
+	}
+	checkEvery := config.CheckEvery
+	if checkEvery <= 0 {
+		checkEvery = DefaultCheckEvery
+	}
+
+	convergenceOpts := analysis.DefaultConvergenceOptions()
+	if config.TargetMargin > 0 {
+		convergenceOpts.TargetMargin = config.TargetMargin
+	}
+
+	fmt.Printf("\n=== Starting adaptive run: %d-%d loops with %d workers, target margin ±%.3f ===\n",
+		minLoops, maxLoops, workerCount, convergenceOpts.TargetMargin)
+
+	// Loops are dispatched one at a time so dispatch can stop at any point
+	workQueue := make(chan int)
+	stop := make(chan struct{})
+	go func() {
+		defer close(workQueue)
+		for i := 1; i <= maxLoops; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			select {
+			case workQueue <- i:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	outcomes := make(chan loopOutcome)
+	var wg sync.WaitGroup
+	for w := 1; w <= workerCount; w++ {
+		wg.Add(1)
+		go func(workerID int) {
+			defer wg.Done()
+			fmt.Printf("Worker %d started\n", workerID)
+
+			for loopNum := range workQueue {
+				fmt.Printf("Worker %d processing loop %d\n", workerID, loopNum)
+				response, err := executeLoop(loopNum, config, outputFile)
+				if err != nil {
+					err = fmt.Errorf("worker %d, loop %d: %v", workerID, loopNum, err)
+				}
+				outcomes <- loopOutcome{loopNum: loopNum, response: response, err: err}
+				fmt.Printf("Worker %d completed loop %d\n", workerID, loopNum)
+			}
+
+			fmt.Printf("Worker %d finished\n", workerID)
+		}(w)
+	}
+
+	go func() {
+		wg.Wait()
+		close(outcomes)
+	}()
+
+	// Collect outcomes and check convergence as loops complete
+	var responses []string
+	completed := 0
+	unanswered := 0 // loops without a subagent reply, left out of the clustering
+	lastCheck := 0
+	converged := false
+	for outcome := range outcomes {
+		completed++
+		if outcome.err != nil {
+			log.Printf("Execution error: %v", outcome.err)
+		} else if outcome.response != "" {
+			// Empty replies would all share one cluster and fake agreement
+			if _, subResponse := analysis.ExtractAgentResponses(outcome.response); strings.TrimSpace(subResponse) != "" {
+				responses = append(responses, subResponse)
+			} else {
+				unanswered++
+			}
+		}
+
+		if converged || completed < minLoops || completed-lastCheck < checkEvery {
+			continue
+		}
+		lastCheck = completed
+
+		check := analysis.CheckConvergence(responses, convergenceOpts)
+		if check.MajorityShare == nil {
+			continue
+		}
+		fmt.Printf("Adaptive check after %d loops: majority share %.3f (%.0f%% CI %.3f-%.3f, ±%.3f) across %d clusters, %d loops without a subagent reply skipped\n",
+			completed, check.MajorityShare.Estimate, check.MajorityShare.Confidence*100,
+			check.MajorityShare.Lower, check.MajorityShare.Upper, check.MajorityShare.Margin(), check.Clusters, unanswered)
+
+		if check.Converged {
+			converged = true
+			close(stop)
+			fmt.Printf("Converged after %d loops, finishing in-flight loops and stopping dispatch\n", completed)
+		}
+	}
+
+	totalDuration := time.Since(startTime)
+	if converged {
+		fmt.Printf("\n=== Adaptive run converged after %d of up to %d loops ===\n", completed, maxLoops)
+	} else {
+		close(stop)
+		fmt.Printf("\n=== Adaptive run reached %d loops without converging ===\n", completed)
+	}
+
+	return &TestResult{
+		OutputFile: outputFile,
+		Duration:   totalDuration,
+		LoopsRun:   completed,
+		Converged:  converged,
+	}, nil
+}
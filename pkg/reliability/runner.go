@@ -11,6 +11,8 @@ import (
 	"sync"
 	"text/template"
 	"time"
+
+	"agent-reliability-tests/pkg/analysis"
 )
 
 type ExecutionMode int
@@ -21,19 +23,25 @@ const (
 )
 
 type TestConfig struct {
-	AgentName        string
-	Loops            int
-	Filename         string
-	Parallel         bool
-	BatchSize        int
-	Queue            int
-	PromptTemplate   string
-	ParsedTemplate   *template.Template // Cached parsed template
+	AgentName      string
+	Loops          int
+	Filename       string
+	Parallel       bool
+	BatchSize      int
+	Queue          int
+	PromptTemplate string
+	ParsedTemplate *template.Template // Cached parsed template
+	Adaptive       bool               // Stop early once the reliability estimate converges
+	MinLoops       int                // Adaptive: loops to complete before checking convergence
+	TargetMargin   float64            // Adaptive: margin of error on the majority-cluster share
+	CheckEvery     int                // Adaptive: completed loops between convergence checks
 }
 
 type TestResult struct {
 	OutputFile string
 	Duration   time.Duration
+	LoopsRun   int
+	Converged  bool // Adaptive: stopped because the estimate converged
 }
 
 type TemplateData struct {
@@ -115,15 +123,40 @@ func RunReliabilityTest(config TestConfig) (*TestResult, error) {
 	case Parallel:
 		mode = "parallel"
 	}
+	if config.Adaptive {
+		if execMode == Parallel {
+			return nil, fmt.Errorf("adaptive mode requires queue mode")
+		}
+		mode += fmt.Sprintf(", adaptive up to %d loops", config.Loops)
+	}
 	fmt.Printf("Running %d loop(s) with agent: %s (%s mode)\n", config.Loops, config.AgentName, mode)
 	fmt.Printf("Output file: %s\n", outputFile)
 
 	startTime := time.Now()
 
+	// Record what was run so logs can be grouped when analyzed later
+	if err := appendToLog(outputFile, runHeader(config, startTime)); err != nil {
+		return nil, fmt.Errorf("failed to write log header: %v", err)
+	}
+
 	// Use unified execution method for both serial and parallel
 	return runLoops(config, outputFile, startTime)
 }
 
+// runHeader describes the run at the top of the log file
+func runHeader(config TestConfig, startTime time.Time) string {
+	template := config.PromptTemplate
+	if template == "" {
+		template = "default"
+	}
+
+	header := fmt.Sprintf("# Agent: %s\n", config.AgentName)
+	header += fmt.Sprintf("# Template: %s\n", template)
+	header += fmt.Sprintf("# Loops: %d\n", config.Loops)
+	header += fmt.Sprintf("# Started: %s\n\n", startTime.UTC().Format("2006-01-02 15:04:05 UTC"))
+	return header
+}
+
 // runLoops executes loops based on the configured execution mode
 func runLoops(config TestConfig, outputFile string, startTime time.Time) (*TestResult, error) {
 	execMode := config.GetExecutionMode()
@@ -135,6 +168,9 @@ func runLoops(config TestConfig, outputFile string, startTime time.Time) (*TestR
 		if workerCount == 0 {
 			workerCount = 1
 		}
+		if config.Adaptive {
+			return runLoopsAdaptive(config, outputFile, startTime, workerCount)
+		}
 		return runLoopsQueue(config, outputFile, startTime, workerCount)
 	case Parallel:
 		return runLoopsParallel(config, outputFile, startTime)
@@ -170,7 +206,7 @@ func runLoopsQueue(config TestConfig, outputFile string, startTime time.Time, wo
 
 			for loopNum := range workQueue {
 				fmt.Printf("Worker %d processing loop %d\n", workerID, loopNum)
-				if err := executeLoop(loopNum, config, outputFile); err != nil {
+				if _, err := executeLoop(loopNum, config, outputFile); err != nil {
 					errorChan <- fmt.Errorf("worker %d, loop %d: %v", workerID, loopNum, err)
 				}
 				fmt.Printf("Worker %d completed loop %d\n", workerID, loopNum)
@@ -195,6 +231,7 @@ func runLoopsQueue(config TestConfig, outputFile string, startTime time.Time, wo
 	return &TestResult{
 		OutputFile: outputFile,
 		Duration:   totalDuration,
+		LoopsRun:   totalLoops,
 	}, nil
 }
 
@@ -228,7 +265,7 @@ func runLoopsParallel(config TestConfig, outputFile string, startTime time.Time)
 			wg.Add(1)
 			go func(loopNum int) {
 				defer wg.Done()
-				if err := executeLoop(loopNum, config, outputFile); err != nil {
+				if _, err := executeLoop(loopNum, config, outputFile); err != nil {
 					errorChan <- fmt.Errorf("loop %d: %v", loopNum, err)
 				}
 			}(currentLoop)
@@ -256,12 +293,13 @@ func runLoopsParallel(config TestConfig, outputFile string, startTime time.Time)
 	return &TestResult{
 		OutputFile: outputFile,
 		Duration:   totalDuration,
+		LoopsRun:   totalLoops,
 	}, nil
 }
 
 
-// executeLoop runs a single test loop
-func executeLoop(loopNum int, config TestConfig, outputFile string) error {
+// executeLoop runs a single test loop and returns the agent's response
+func executeLoop(loopNum int, config TestConfig, outputFile string) (string, error) {
 	// Create the prompt using either the cached parsed template or the default pattern
 	var prompt string
 	if config.ParsedTemplate != nil {
@@ -272,7 +310,7 @@ func executeLoop(loopNum int, config TestConfig, outputFile string) error {
 		
 		var buf bytes.Buffer
 		if err := config.ParsedTemplate.Execute(&buf, templateData); err != nil {
-			return fmt.Errorf("failed to execute template: %v", err)
+			return "", fmt.Errorf("failed to execute template: %v", err)
 		}
 		prompt = strings.TrimSpace(buf.String())
 	} else {
@@ -284,7 +322,8 @@ func executeLoop(loopNum int, config TestConfig, outputFile string) error {
 	fmt.Printf("Loop %d: Prompt: %s\n\n", loopNum, prompt)
 
 	// Execute claude with the specified flags and prompt
-	claudeCmd := exec.Command("claude", "-p", "--permission-mode", "acceptEdits", prompt)
+	// stream-json output includes the tool calls, so delegation can be verified
+	claudeCmd := exec.Command("claude", "-p", "--permission-mode", "acceptEdits", "--output-format", "stream-json", "--verbose", prompt)
 
 	// Capture output in buffers
 	var stdout, stderr bytes.Buffer
@@ -302,9 +341,15 @@ func executeLoop(loopNum int, config TestConfig, outputFile string) error {
 	// Record end time
 	loopEndTime := time.Now()
 
+	// Fall back to the raw output when the CLI did not produce stream-json
+	response, toolCalls, traced := parseStreamOutput(stdout.String())
+	if !traced {
+		response = stdout.String()
+	}
+
 	// Display output to console
-	if stdout.Len() > 0 {
-		fmt.Printf("Loop %d output:\n%s\n", loopNum, stdout.String())
+	if len(response) > 0 {
+		fmt.Printf("Loop %d output:\n%s\n", loopNum, response)
 	}
 	if stderr.Len() > 0 {
 		fmt.Fprintf(os.Stderr, "Loop %d stderr:\n%s\n", loopNum, stderr.String())
@@ -312,10 +357,14 @@ func executeLoop(loopNum int, config TestConfig, outputFile string) error {
 
 	// Log the interaction
 	logEntry := fmt.Sprintf("=== Loop %d/%d - %s ===\n", loopNum, config.Loops, loopEndTime.UTC().Format("2006-01-02 15:04:05 UTC"))
-	logEntry += fmt.Sprintf("Prompt: %s\n", prompt)
-	logEntry += fmt.Sprintf("Response:\n%s\n", strings.TrimSpace(stdout.String()))
+	// Sections are length-prefixed so content is preserved byte for byte
+	logEntry += logSection("Prompt", prompt)
+	logEntry += logSection("Response", response)
+	if traced {
+		logEntry += logSection("Tools", analysis.FormatToolTrace(toolCalls))
+	}
 	if stderr.Len() > 0 {
-		logEntry += fmt.Sprintf("Errors:\n%s\n", strings.TrimSpace(stderr.String()))
+		logEntry += logSection("Errors", stderr.String())
 	}
 	logEntry += fmt.Sprintf("Execution time: %v\n", loopEndTime.Sub(loopStartTime))
 	logEntry += "---\n\n"
@@ -326,13 +375,13 @@ func executeLoop(loopNum int, config TestConfig, outputFile string) error {
 	}
 
 	if err != nil {
-		return fmt.Errorf("claude execution failed: %v", err)
+		return "", fmt.Errorf("claude execution failed: %v", err)
 	}
 
 	fmt.Printf("Loop %d: Execution completed at: %s\n", loopNum, loopEndTime.Format("2006-01-02 15:04:05"))
 	fmt.Printf("Loop %d: Total execution time: %v\n", loopNum, loopEndTime.Sub(loopStartTime))
 
-	return nil
+	return strings.TrimSpace(response), nil
 }
 
 func appendToLog(filename, content string) error {
@@ -346,6 +395,12 @@ func appendToLog(filename, content string) error {
 	return err
 }
 
+// logSection formats a log section whose content may span any number of
+// lines, prefixed with its length in bytes
+func logSection(name, content string) string {
+	return fmt.Sprintf("%s (%d bytes):\n%s\n", name, len(content), content)
+}
+
 // appendToLogThreadSafe provides thread-safe logging for parallel execution
 func appendToLogThreadSafe(filename, content string) error {
 	logMutex.Lock()
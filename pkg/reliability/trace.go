@@ -0,0 +1,104 @@
+package reliability
+
+import (
+	"bufio"
+	"encoding/json"
+	"strings"
+
+	"agent-reliability-tests/pkg/analysis"
+)
+
+// streamEvent is one line of the CLI's stream-json output
+type streamEvent struct {
+	Type    string `json:"type"`
+	Message *struct {
+		Content []streamContent `json:"content"`
+	} `json:"message"`
+	ParentToolUseID *string `json:"parent_tool_use_id"`
+	Result          *string `json:"result"`
+}
+
+// streamContent is a content block of an assistant message
+type streamContent struct {
+	Type  string          `json:"type"`
+	Text  string          `json:"text"`
+	ID    string          `json:"id"`
+	Name  string          `json:"name"`
+	Input json.RawMessage `json:"input"`
+}
+
+// parseStreamOutput extracts the final response and the tool calls from
+// stream-json output. It returns ok false when the output is not
+// stream-json, e.g. from an older CLI, or is empty because the CLI failed,
+// so the caller logs it as text rather than as a traced loop.
+func parseStreamOutput(output string) (response string, calls []analysis.ToolCall, ok bool) {
+	calls = []analysis.ToolCall{}
+	var lastText string
+	var result *string
+	events := 0
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var event streamEvent
+		if err := json.Unmarshal([]byte(line), &event); err != nil || event.Type == "" {
+			return "", nil, false
+		}
+		events++
+
+		switch event.Type {
+		case "assistant":
+			if event.Message == nil {
+				continue
+			}
+			parent := ""
+			if event.ParentToolUseID != nil {
+				parent = *event.ParentToolUseID
+			}
+			var text []string
+			for _, content := range event.Message.Content {
+				switch content.Type {
+				case "text":
+					text = append(text, content.Text)
+				case "tool_use":
+					calls = append(calls, toolCall(content, parent))
+				}
+			}
+			// Keep the main agent's latest text in case no result arrives
+			if parent == "" && len(text) > 0 {
+				lastText = strings.Join(text, "\n")
+			}
+		case "result":
+			result = event.Result
+		}
+	}
+	if scanner.Err() != nil || events == 0 {
+		return "", nil, false
+	}
+
+	if result != nil {
+		return *result, calls, true
+	}
+	return lastText, calls, true
+}
+
+// toolCall converts a tool_use block, keeping only what identifies a
+// delegation
+func toolCall(content streamContent, parent string) analysis.ToolCall {
+	call := analysis.ToolCall{ID: content.ID, Name: content.Name, Parent: parent}
+	if call.IsSubagentCall() {
+		var input struct {
+			SubagentType string `json:"subagent_type"`
+			Description  string `json:"description"`
+		}
+		if json.Unmarshal(content.Input, &input) == nil {
+			call.SubagentType = input.SubagentType
+			call.Description = input.Description
+		}
+	}
+	return call
+}